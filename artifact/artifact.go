@@ -0,0 +1,305 @@
+package artifact
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/consensys/gnark"
+	"github.com/consensys/gnark-crypto/ecc"
+)
+
+// Artifact files (ccs, pk, vk, proof, for PLONK or Groth16) are stored in a self-describing container:
+//
+//	magic          [4]byte  "LTAF"
+//	format version uint16
+//	kind           uint8
+//	curve          uint16   ecc.ID
+//	gnark version  uint8 length + bytes
+//	fingerprint    uint8 length + bytes, optional vk fingerprint
+//	payload length uint64
+//	payload hash   [32]byte SHA-256 of the payload
+//	payload        the gnark serialized object
+//
+// All integers are big-endian. Files without the magic are legacy headerless gnark blobs.
+
+const FormatVersion uint16 = 1
+
+var Magic = [4]byte{'L', 'T', 'A', 'F'}
+
+var (
+	ErrLegacy          = errors.New("artifact: legacy headerless file")
+	ErrFormatVersion   = errors.New("artifact: unsupported format version")
+	ErrKindMismatch    = errors.New("artifact: kind mismatch")
+	ErrCurveMismatch   = errors.New("artifact: curve mismatch")
+	ErrLengthMismatch  = errors.New("artifact: payload length mismatch")
+	ErrHashMismatch    = errors.New("artifact: payload hash mismatch")
+	ErrFieldTooLong    = errors.New("artifact: header field too long")
+	ErrTrailingPayload = errors.New("artifact: payload not fully consumed")
+)
+
+type Kind uint8
+
+const (
+	KindCcs Kind = iota + 1
+	KindPk
+	KindVk
+	KindProof
+	KindR1cs
+	KindGroth16Pk
+	KindGroth16Vk
+	KindGroth16Proof
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindCcs:
+		return "ccs"
+	case KindPk:
+		return "pk"
+	case KindVk:
+		return "vk"
+	case KindProof:
+		return "proof"
+	case KindR1cs:
+		return "r1cs"
+	case KindGroth16Pk:
+		return "groth16 pk"
+	case KindGroth16Vk:
+		return "groth16 vk"
+	case KindGroth16Proof:
+		return "groth16 proof"
+	default:
+		return fmt.Sprintf("kind(%d)", uint8(k))
+	}
+}
+
+type Header struct {
+	FormatVersion uint16
+	Kind          Kind
+	Curve         ecc.ID
+	GnarkVersion  string
+	FingerPrint   []byte
+	PayloadLength uint64
+	PayloadHash   [sha256.Size]byte
+}
+
+// NewHeader returns a header for kind and curve stamped with the current gnark version.
+func NewHeader(kind Kind, curve ecc.ID, fingerPrint []byte) *Header {
+	return &Header{
+		FormatVersion: FormatVersion,
+		Kind:          kind,
+		Curve:         curve,
+		GnarkVersion:  gnark.Version.String(),
+		FingerPrint:   fingerPrint,
+	}
+}
+
+func (h *Header) size() int64 {
+	return int64(len(Magic) + 2 + 1 + 2 + 1 + len(h.GnarkVersion) + 1 + len(h.FingerPrint) + 8 + sha256.Size)
+}
+
+func (h *Header) encode() ([]byte, error) {
+	if len(h.GnarkVersion) > 0xff || len(h.FingerPrint) > 0xff {
+		return nil, ErrFieldTooLong
+	}
+
+	buf := bytes.NewBuffer(make([]byte, 0, h.size()))
+	buf.Write(Magic[:])
+	_ = binary.Write(buf, binary.BigEndian, h.FormatVersion)
+	buf.WriteByte(byte(h.Kind))
+	_ = binary.Write(buf, binary.BigEndian, uint16(h.Curve))
+	buf.WriteByte(byte(len(h.GnarkVersion)))
+	buf.WriteString(h.GnarkVersion)
+	buf.WriteByte(byte(len(h.FingerPrint)))
+	buf.Write(h.FingerPrint)
+	_ = binary.Write(buf, binary.BigEndian, h.PayloadLength)
+	buf.Write(h.PayloadHash[:])
+	return buf.Bytes(), nil
+}
+
+// Write writes hdr followed by the payload to w. The header space is reserved first, the payload is
+// streamed while being hashed, and then the completed header is written back at offset 0, so the
+// payload never has to be held in memory. PayloadLength and PayloadHash of hdr are filled in.
+func Write(w io.WriteSeeker, hdr *Header, payload func(w io.Writer) (int64, error)) error {
+	_, err := w.Seek(hdr.size(), io.SeekStart)
+	if err != nil {
+		return err
+	}
+
+	hasher := sha256.New()
+	bw := bufio.NewWriterSize(io.MultiWriter(w, hasher), 1<<20)
+	n, err := payload(bw)
+	if err != nil {
+		return err
+	}
+	err = bw.Flush()
+	if err != nil {
+		return err
+	}
+
+	hdr.PayloadLength = uint64(n)
+	copy(hdr.PayloadHash[:], hasher.Sum(nil))
+	encoded, err := hdr.encode()
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Seek(0, io.SeekStart)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(encoded)
+	return err
+}
+
+// ReadHeader reads a container header from r. It returns ErrLegacy if r does not start with Magic.
+func ReadHeader(r io.Reader) (*Header, error) {
+	var magic [len(Magic)]byte
+	_, err := io.ReadFull(r, magic[:])
+	if err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, ErrLegacy
+		}
+		return nil, err
+	}
+	if magic != Magic {
+		return nil, ErrLegacy
+	}
+
+	var hdr Header
+	err = binary.Read(r, binary.BigEndian, &hdr.FormatVersion)
+	if err != nil {
+		return nil, err
+	}
+	if hdr.FormatVersion != FormatVersion {
+		return nil, fmt.Errorf("%w: %d", ErrFormatVersion, hdr.FormatVersion)
+	}
+
+	var fixed [3]byte
+	_, err = io.ReadFull(r, fixed[:])
+	if err != nil {
+		return nil, err
+	}
+	hdr.Kind = Kind(fixed[0])
+	hdr.Curve = ecc.ID(binary.BigEndian.Uint16(fixed[1:]))
+
+	version, err := readShortBytes(r)
+	if err != nil {
+		return nil, err
+	}
+	hdr.GnarkVersion = string(version)
+
+	hdr.FingerPrint, err = readShortBytes(r)
+	if err != nil {
+		return nil, err
+	}
+
+	err = binary.Read(r, binary.BigEndian, &hdr.PayloadLength)
+	if err != nil {
+		return nil, err
+	}
+	_, err = io.ReadFull(r, hdr.PayloadHash[:])
+	if err != nil {
+		return nil, err
+	}
+	return &hdr, nil
+}
+
+func readShortBytes(r io.Reader) ([]byte, error) {
+	var l [1]byte
+	_, err := io.ReadFull(r, l[:])
+	if err != nil {
+		return nil, err
+	}
+	if l[0] == 0 {
+		return nil, nil
+	}
+	b := make([]byte, l[0])
+	_, err = io.ReadFull(r, b)
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Read reads an artifact of the expected kind and curve from r and hands its payload to payload.
+// The payload length and hash are checked once the payload is consumed.
+// Legacy headerless files are passed to payload unchanged, and a nil header is returned for them.
+func Read(r io.Reader, kind Kind, curve ecc.ID, payload func(r io.Reader) (int64, error)) (*Header, error) {
+	br := bufio.NewReaderSize(r, 1<<20)
+	peek, err := br.Peek(len(Magic))
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	if !bytes.Equal(peek, Magic[:]) {
+		_, err = payload(br)
+		return nil, err
+	}
+
+	hdr, err := ReadHeader(br)
+	if err != nil {
+		return nil, err
+	}
+	err = hdr.Check(kind, curve)
+	if err != nil {
+		return nil, err
+	}
+
+	hasher := sha256.New()
+	counter := &countingReader{r: io.TeeReader(io.LimitReader(br, int64(hdr.PayloadLength)), hasher)}
+	_, err = payload(counter)
+	if err != nil {
+		return nil, err
+	}
+
+	// decoders may stop short of the payload end; the hash must still cover all of it
+	rest, err := io.Copy(io.Discard, counter)
+	if err != nil {
+		return nil, err
+	}
+	if counter.n != int64(hdr.PayloadLength) {
+		return nil, fmt.Errorf("%w: header says %d bytes, read %d", ErrLengthMismatch, hdr.PayloadLength, counter.n)
+	}
+	if !bytes.Equal(hasher.Sum(nil), hdr.PayloadHash[:]) {
+		return nil, ErrHashMismatch
+	}
+	if rest != 0 {
+		return nil, fmt.Errorf("%w: %d bytes left", ErrTrailingPayload, rest)
+	}
+	return hdr, nil
+}
+
+// Check verifies that hdr describes an artifact of the expected kind and curve.
+func (h *Header) Check(kind Kind, curve ecc.ID) error {
+	if h.Kind != kind {
+		return fmt.Errorf("%w: expected %v, got %v", ErrKindMismatch, kind, h.Kind)
+	}
+	if h.Curve != curve {
+		return fmt.Errorf("%w: expected %v, got %v", ErrCurveMismatch, CurveName(curve), CurveName(h.Curve))
+	}
+	return nil
+}
+
+// CurveName is ecc.ID.String without panicking on unknown ids read from corrupted headers.
+func CurveName(curve ecc.ID) string {
+	if curve == ecc.UNKNOWN || curve > ecc.GRUMPKIN {
+		return fmt.Sprintf("curve(%d)", uint16(curve))
+	}
+	return curve.String()
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
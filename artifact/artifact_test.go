@@ -0,0 +1,99 @@
+package artifact
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/stretchr/testify/assert"
+)
+
+func writePayload(data []byte) func(w io.Writer) (int64, error) {
+	return func(w io.Writer) (int64, error) {
+		n, err := w.Write(data)
+		return int64(n), err
+	}
+}
+
+func readPayload(dst *[]byte) func(r io.Reader) (int64, error) {
+	return func(r io.Reader) (int64, error) {
+		data, err := io.ReadAll(r)
+		*dst = data
+		return int64(len(data)), err
+	}
+}
+
+func writeContainer(t *testing.T, hdr *Header, payload []byte) []byte {
+	fn := filepath.Join(t.TempDir(), "artifact")
+	f, err := os.Create(fn)
+	assert.NoError(t, err)
+	err = Write(f, hdr, writePayload(payload))
+	assert.NoError(t, err)
+	assert.NoError(t, f.Close())
+
+	content, err := os.ReadFile(fn)
+	assert.NoError(t, err)
+	return content
+}
+
+func TestRoundTrip(t *testing.T) {
+	payload := bytes.Repeat([]byte("payload"), 1000)
+	fp := []byte{1, 2, 3, 4}
+	content := writeContainer(t, NewHeader(KindVk, ecc.BN254, fp), payload)
+
+	var got []byte
+	hdr, err := Read(bytes.NewReader(content), KindVk, ecc.BN254, readPayload(&got))
+	assert.NoError(t, err)
+	assert.Equal(t, payload, got)
+	assert.Equal(t, FormatVersion, hdr.FormatVersion)
+	assert.Equal(t, KindVk, hdr.Kind)
+	assert.Equal(t, ecc.BN254, hdr.Curve)
+	assert.Equal(t, fp, hdr.FingerPrint)
+	assert.Equal(t, uint64(len(payload)), hdr.PayloadLength)
+	assert.NotEmpty(t, hdr.GnarkVersion)
+}
+
+func TestLegacy(t *testing.T) {
+	payload := []byte("raw gnark blob")
+
+	var got []byte
+	hdr, err := Read(bytes.NewReader(payload), KindPk, ecc.BN254, readPayload(&got))
+	assert.NoError(t, err)
+	assert.Nil(t, hdr)
+	assert.Equal(t, payload, got)
+
+	_, err = ReadHeader(bytes.NewReader(payload))
+	assert.ErrorIs(t, err, ErrLegacy)
+}
+
+func TestMismatch(t *testing.T) {
+	payload := bytes.Repeat([]byte{0x42}, 4096)
+	content := writeContainer(t, NewHeader(KindPk, ecc.BN254, nil), payload)
+
+	var got []byte
+	_, err := Read(bytes.NewReader(content), KindVk, ecc.BN254, readPayload(&got))
+	assert.ErrorIs(t, err, ErrKindMismatch)
+
+	_, err = Read(bytes.NewReader(content), KindPk, ecc.BLS12_381, readPayload(&got))
+	assert.ErrorIs(t, err, ErrCurveMismatch)
+
+	corrupted := bytes.Clone(content)
+	corrupted[len(corrupted)-1] ^= 0xff
+	_, err = Read(bytes.NewReader(corrupted), KindPk, ecc.BN254, readPayload(&got))
+	assert.ErrorIs(t, err, ErrHashMismatch)
+
+	truncated := content[:len(content)-100]
+	_, err = Read(bytes.NewReader(truncated), KindPk, ecc.BN254, readPayload(&got))
+	assert.ErrorIs(t, err, ErrLengthMismatch)
+
+	// a decoder stopping short of the payload end is reported
+	_, err = Read(bytes.NewReader(content), KindPk, ecc.BN254, func(r io.Reader) (int64, error) {
+		buf := make([]byte, 10)
+		n, err := io.ReadFull(r, buf)
+		return int64(n), err
+	})
+	assert.ErrorIs(t, err, ErrTrailingPayload)
+}
@@ -0,0 +1,143 @@
+// Command srs generates and manages the <curve>_pow_<power>.srs and .lsrs files read by operations.ReadSrs.
+//
+//	srs gen -dir srs -curve bn254 -power 20 [-seed dev]    unsafe SRS, for development only
+//	srs lagrange -dir srs -curve bn254 -power 20           .lsrs from the .srs of the same power
+//	srs trim -dir srs -curve bn254 -from 22 -power 20      .srs and .lsrs of power from a larger .srs
+//	srs import -dir srs -ptau powersOfTau28_hez_final_20.ptau -power 20   from a snarkjs ceremony transcript
+//	srs ls -dir srs
+//	srs inspect file...
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/lightec-xyz/common/operations"
+)
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: srs gen|lagrange|trim|import|ls|inspect [flags]")
+	os.Exit(2)
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	cmd, args := os.Args[1], os.Args[2:]
+
+	fs := flag.NewFlagSet(cmd, flag.ExitOnError)
+	dir := fs.String("dir", ".", "srs directory")
+	curveName := fs.String("curve", "bn254", "curve")
+	power := fs.Int("power", 0, "srs power, for circuits up to 2^power constraints")
+	from := fs.Int("from", 0, "power of the srs to trim")
+	seed := fs.String("seed", "", "toxic seed, random if empty")
+	ptau := fs.String("ptau", "", "bn254 snarkjs powers of tau transcript to import")
+	_ = fs.Parse(args)
+
+	curve, err := ecc.IDFromString(*curveName)
+	if err != nil {
+		fail(err)
+	}
+
+	switch cmd {
+	case "gen":
+		var s []byte
+		if *seed != "" {
+			s = []byte(*seed)
+		}
+		srs, lsrs, err := operations.GenerateUnsafeSrs(curve, *power, s)
+		if err != nil {
+			fail(err)
+		}
+		err = operations.WriteSrs(*dir, *power, srs, lsrs)
+		if err != nil {
+			fail(err)
+		}
+	case "lagrange":
+		srsFile, _ := operations.SrsFiles(*dir, curve, *power)
+		srs, err := operations.ReadSrsFile(srsFile, curve)
+		if err != nil {
+			fail(err)
+		}
+		lsrs, err := operations.LagrangeSrs(srs, *power)
+		if err != nil {
+			fail(err)
+		}
+		err = operations.WriteSrs(*dir, *power, srs, lsrs)
+		if err != nil {
+			fail(err)
+		}
+	case "trim":
+		if *from <= *power {
+			fail(fmt.Errorf("-from %v must be larger than -power %v", *from, *power))
+		}
+		srsFile, _ := operations.SrsFiles(*dir, curve, *from)
+		larger, err := operations.ReadSrsFile(srsFile, curve)
+		if err != nil {
+			fail(err)
+		}
+		srs, err := operations.TrimSrs(larger, *power)
+		if err != nil {
+			fail(err)
+		}
+		lsrs, err := operations.LagrangeSrs(srs, *power)
+		if err != nil {
+			fail(err)
+		}
+		err = operations.WriteSrs(*dir, *power, srs, lsrs)
+		if err != nil {
+			fail(err)
+		}
+	case "import":
+		source, err := operations.ImportPtau(*ptau, *dir, *power)
+		if err != nil {
+			fail(err)
+		}
+		fmt.Printf("imported pow %v from %v, sha256 %v\n", *power, source.File, source.Sha256)
+	case "ls":
+		infos, err := operations.ListSrs(*dir)
+		if err != nil {
+			fail(err)
+		}
+		for _, info := range infos {
+			printInfo(&info)
+		}
+	case "inspect":
+		failed := false
+		for _, fn := range fs.Args() {
+			info, err := operations.InspectSrs(fn)
+			if info != nil {
+				printInfo(info)
+			}
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				failed = true
+			}
+		}
+		if failed {
+			os.Exit(1)
+		}
+	default:
+		usage()
+	}
+}
+
+func printInfo(info *operations.SrsInfo) {
+	kind := "canonical"
+	if info.Lagrange {
+		kind = "lagrange"
+	}
+	fmt.Printf("%v\t%v\tpow %v\t%v\t%v bytes", info.File, info.Curve, info.Power, kind, info.Size)
+	if info.NbG1 > 0 {
+		fmt.Printf("\t%v G1 points", info.NbG1)
+	}
+	fmt.Println()
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
@@ -0,0 +1,269 @@
+package operations
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/groth16"
+	native_plonk "github.com/consensys/gnark/backend/plonk"
+	plonk_bls12377 "github.com/consensys/gnark/backend/plonk/bls12-377"
+	plonk_bls12381 "github.com/consensys/gnark/backend/plonk/bls12-381"
+	plonk_bn254 "github.com/consensys/gnark/backend/plonk/bn254"
+	plonk_bw6761 "github.com/consensys/gnark/backend/plonk/bw6-761"
+	"github.com/consensys/gnark/backend/witness"
+	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/frontend"
+	"github.com/lightec-xyz/common/artifact"
+)
+
+// proofSystem is what CircuitOperations needs from a backend, see Config.Backend. The keys and proofs it
+// takes must come from the same backend and curve, an error is returned otherwise.
+type proofSystem interface {
+	id() backend.ID
+	compile(circuit frontend.Circuit, curve ecc.ID) (constraint.ConstraintSystem, error)
+	// setup reads the SRS from srsDir if the backend needs one
+	setup(ccs constraint.ConstraintSystem, srsDir string) (ProvingKey, VerifyingKey, error)
+	prove(ctx context.Context, ccs constraint.ConstraintSystem, pk ProvingKey, wit witness.Witness, isFront bool) (SnarkProof, error)
+	verify(vk VerifyingKey, proof SnarkProof, wit witness.Witness, isFront bool) error
+	validate(ccs constraint.ConstraintSystem, pk ProvingKey, vk VerifyingKey) error
+
+	// readPk skips subgroup checks if trusted, see ReadPkRaw
+	readPk(ctx context.Context, fn string, curve ecc.ID, trusted bool) (ProvingKey, error)
+	readVk(ctx context.Context, fn string, curve ecc.ID) (VerifyingKey, error)
+	// writePk writes uncompressed points if raw, see WritePkRaw
+	writePk(pk ProvingKey, fn string, raw bool) error
+	writeVk(vk VerifyingKey, fn string) error
+}
+
+func proofSystemOf(id backend.ID) (proofSystem, error) {
+	switch id {
+	case backend.PLONK:
+		return plonkSystem{}, nil
+	case backend.GROTH16:
+		return groth16System{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported backend %v", id)
+	}
+}
+
+// proofSystemOfFile returns the backend of the key or proof fn from its header, PLONK for legacy files
+func proofSystemOfFile(fn string) proofSystem {
+	hdr, err := ReadArtifactHeader(fn)
+	if err == nil {
+		switch hdr.Kind {
+		case artifact.KindR1cs, artifact.KindGroth16Pk, artifact.KindGroth16Vk, artifact.KindGroth16Proof:
+			return groth16System{}
+		}
+	}
+	return plonkSystem{}
+}
+
+type plonkSystem struct{}
+
+func (plonkSystem) id() backend.ID {
+	return backend.PLONK
+}
+
+func (plonkSystem) compile(circuit frontend.Circuit, curve ecc.ID) (constraint.ConstraintSystem, error) {
+	return NewConstraintSystemWithCurve(circuit, curve)
+}
+
+func (s plonkSystem) setup(ccs constraint.ConstraintSystem, srsDir string) (ProvingKey, VerifyingKey, error) {
+	curve, err := sameCurve(s, ccs)
+	if err != nil {
+		return nil, nil, err
+	}
+	srs, lsrs, err := ReadSrsWithCurve(ccs.GetNbConstraints()+ccs.GetNbPublicVariables(), srsDir, curve)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read srs: %w", err)
+	}
+	return PlonkSetup(ccs, srs, lsrs)
+}
+
+func (s plonkSystem) prove(ctx context.Context, ccs constraint.ConstraintSystem, pk ProvingKey, wit witness.Witness, isFront bool) (SnarkProof, error) {
+	_, err := sameCurve(s, ccs, pk)
+	if err != nil {
+		return nil, err
+	}
+	return PlonkProveWitnessCtx(ctx, ccs, pk.(native_plonk.ProvingKey), wit, isFront)
+}
+
+func (s plonkSystem) verify(vk VerifyingKey, proof SnarkProof, wit witness.Witness, isFront bool) error {
+	_, err := sameCurve(s, vk, proof)
+	if err != nil {
+		return err
+	}
+	return PlonkVerify(vk, proof, wit, isFront)
+}
+
+func (s plonkSystem) validate(ccs constraint.ConstraintSystem, pk ProvingKey, vk VerifyingKey) error {
+	err := checkCurves(ccs, pk, vk)
+	if err != nil {
+		return err
+	}
+	_, err = sameCurve(s, ccs, pk, vk)
+	if err != nil {
+		return inconsistent("%v", err)
+	}
+	return validatePlonk(ccs, pk.(native_plonk.ProvingKey), vk)
+}
+
+func (plonkSystem) readPk(ctx context.Context, fn string, curve ecc.ID, trusted bool) (ProvingKey, error) {
+	return ReadPkRawCtx(ctx, fn, curve, trusted)
+}
+
+func (plonkSystem) readVk(ctx context.Context, fn string, curve ecc.ID) (VerifyingKey, error) {
+	return ReadVkCtx(ctx, fn, curve)
+}
+
+func (s plonkSystem) writePk(pk ProvingKey, fn string, raw bool) error {
+	_, err := sameCurve(s, pk)
+	if err != nil {
+		return err
+	}
+	if raw {
+		return WritePkRaw(pk.(native_plonk.ProvingKey), fn)
+	}
+	return WritePk(pk.(native_plonk.ProvingKey), fn)
+}
+
+func (s plonkSystem) writeVk(vk VerifyingKey, fn string) error {
+	_, err := sameCurve(s, vk)
+	if err != nil {
+		return err
+	}
+	return WriteVk(vk, fn)
+}
+
+// curveOf returns the curve of a plonk ccs, key or proof, false for anything else, e.g. groth16 keys which
+// would satisfy the plonk.VerifyingKey and plonk.Proof interfaces
+func (plonkSystem) curveOf(v interface{}) (ecc.ID, bool) {
+	switch v.(type) {
+	case constraint.SparseR1CS[constraint.U64]:
+		curve, err := CurveOf(v)
+		return curve, err == nil
+	case *plonk_bn254.ProvingKey, *plonk_bn254.VerifyingKey, *plonk_bn254.Proof:
+		return ecc.BN254, true
+	case *plonk_bls12377.ProvingKey, *plonk_bls12377.VerifyingKey, *plonk_bls12377.Proof:
+		return ecc.BLS12_377, true
+	case *plonk_bls12381.ProvingKey, *plonk_bls12381.VerifyingKey, *plonk_bls12381.Proof:
+		return ecc.BLS12_381, true
+	case *plonk_bw6761.ProvingKey, *plonk_bw6761.VerifyingKey, *plonk_bw6761.Proof:
+		return ecc.BW6_761, true
+	default:
+		return ecc.UNKNOWN, false
+	}
+}
+
+type groth16System struct{}
+
+func (groth16System) id() backend.ID {
+	return backend.GROTH16
+}
+
+func (groth16System) compile(circuit frontend.Circuit, curve ecc.ID) (constraint.ConstraintSystem, error) {
+	return NewR1CSConstraintSystem(circuit, curve)
+}
+
+// setup ignores srsDir, the Groth16 setup is circuit specific
+func (s groth16System) setup(ccs constraint.ConstraintSystem, _ string) (ProvingKey, VerifyingKey, error) {
+	_, err := sameCurve(s, ccs)
+	if err != nil {
+		return nil, nil, err
+	}
+	return Groth16Setup(ccs)
+}
+
+func (s groth16System) prove(ctx context.Context, ccs constraint.ConstraintSystem, pk ProvingKey, wit witness.Witness, isFront bool) (SnarkProof, error) {
+	_, err := sameCurve(s, ccs, pk)
+	if err != nil {
+		return nil, err
+	}
+	return Groth16ProveWitnessCtx(ctx, ccs, pk.(groth16.ProvingKey), wit, isFront)
+}
+
+func (s groth16System) verify(vk VerifyingKey, proof SnarkProof, wit witness.Witness, isFront bool) error {
+	_, err := sameCurve(s, vk, proof)
+	if err != nil {
+		return err
+	}
+	return Groth16Verify(vk.(groth16.VerifyingKey), proof.(groth16.Proof), wit, isFront)
+}
+
+func (s groth16System) validate(ccs constraint.ConstraintSystem, pk ProvingKey, vk VerifyingKey) error {
+	err := checkCurves(ccs, pk, vk)
+	if err != nil {
+		return err
+	}
+	_, err = sameCurve(s, ccs, pk, vk)
+	if err != nil {
+		return inconsistent("%v", err)
+	}
+	return validateGroth16(ccs, pk.(groth16.ProvingKey), vk.(groth16.VerifyingKey))
+}
+
+func (groth16System) readPk(ctx context.Context, fn string, curve ecc.ID, trusted bool) (ProvingKey, error) {
+	return ReadGroth16PkCtx(ctx, fn, curve, trusted)
+}
+
+func (groth16System) readVk(ctx context.Context, fn string, curve ecc.ID) (VerifyingKey, error) {
+	return ReadGroth16VkCtx(ctx, fn, curve)
+}
+
+func (s groth16System) writePk(pk ProvingKey, fn string, raw bool) error {
+	_, err := sameCurve(s, pk)
+	if err != nil {
+		return err
+	}
+	if raw {
+		return WriteGroth16PkRaw(pk.(groth16.ProvingKey), fn)
+	}
+	return WriteGroth16Pk(pk.(groth16.ProvingKey), fn)
+}
+
+func (s groth16System) writeVk(vk VerifyingKey, fn string) error {
+	_, err := sameCurve(s, vk)
+	if err != nil {
+		return err
+	}
+	return WriteGroth16Vk(vk.(groth16.VerifyingKey), fn)
+}
+
+// curveOf returns the curve of a groth16 ccs, key or proof, false for anything else
+func (groth16System) curveOf(v interface{}) (ecc.ID, bool) {
+	switch r := v.(type) {
+	case constraint.R1CS[constraint.U64]:
+		curve, err := CurveOf(v)
+		return curve, err == nil
+	case groth16.ProvingKey:
+		return r.CurveID(), true
+	case groth16.VerifyingKey:
+		return r.CurveID(), true
+	case groth16.Proof:
+		return r.CurveID(), true
+	default:
+		return ecc.UNKNOWN, false
+	}
+}
+
+// sameCurve checks that values all belong to the backend s and share a curve, which it returns. gnark panics
+// on a key of another backend or curve, e.g. one read from the wrong file.
+func sameCurve(s interface {
+	id() backend.ID
+	curveOf(v interface{}) (ecc.ID, bool)
+}, values ...interface{}) (ecc.ID, error) {
+	var curve ecc.ID
+	for i, v := range values {
+		id, ok := s.curveOf(v)
+		if !ok {
+			return ecc.UNKNOWN, fmt.Errorf("expected a %v ccs, key or proof, got %T", s.id(), v)
+		}
+		if i > 0 && id != curve {
+			return ecc.UNKNOWN, fmt.Errorf("%T on %v, expected %v", v, id, curve)
+		}
+		curve = id
+	}
+	return curve, nil
+}
@@ -0,0 +1,95 @@
+package operations
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/consensys/gnark/frontend"
+)
+
+// BatchOptions configures ProveBatch
+type BatchOptions struct {
+	// Workers is the number of proofs made in parallel, 1 if not set. Each proof is already parallel,
+	// more workers mostly help to overlap the sequential parts of proving.
+	Workers int
+	// IsFront is the isFront of ProveWithAssignment
+	IsFront bool
+	// ProgressEvery is the number of proofs between two progress logs, every tenth of the batch if not set
+	ProgressEvery int
+}
+
+// BatchResult is the result of proving assignments[Index]
+type BatchResult struct {
+	Index int
+	Proof *Proof
+	Err   error
+}
+
+// ProveBatch proves assignments with the loaded ccs and pk, on opts.Workers workers. Every assignment gets
+// exactly one result on the returned channel, in completion order, and the channel is closed once they are
+// all done, so it never blocks the workers even if the caller stops reading. Once ctx is done the remaining
+// assignments fail with a *CanceledError. Progress is logged on the component Logger.
+func (c *CircuitOperations) ProveBatch(ctx context.Context, assignments []frontend.Circuit, opts BatchOptions) (<-chan BatchResult, error) {
+	if c.Ccs == nil || c.ProvingKey == nil {
+		return nil, fmt.Errorf("%v: ccs and pk must be loaded before proving", c.ComponentName)
+	}
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = 1
+	}
+	if workers > len(assignments) {
+		workers = len(assignments)
+	}
+	progressEvery := opts.ProgressEvery
+	if progressEvery <= 0 {
+		progressEvery = max(len(assignments)/10, 1)
+	}
+
+	results := make(chan BatchResult, len(assignments))
+	indexes := make(chan int, len(assignments))
+	for i := range assignments {
+		indexes <- i
+	}
+	close(indexes)
+
+	var (
+		wg     sync.WaitGroup
+		done   atomic.Int64
+		failed atomic.Int64
+		start  = time.Now()
+	)
+	c.Logger.Info().Msgf("proving %v %v assignments on %v workers", len(assignments), c.ComponentName, workers)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				var result BatchResult
+				if err := ctx.Err(); err != nil {
+					result = BatchResult{Index: i, Err: &CanceledError{Phase: PhaseWitness, Err: err}}
+				} else {
+					proof, err := c.ProveWithAssignmentCtx(ctx, assignments[i], opts.IsFront)
+					result = BatchResult{Index: i, Proof: proof, Err: err}
+				}
+				if result.Err != nil {
+					failed.Add(1)
+				}
+				results <- result
+
+				n := done.Add(1)
+				if n%int64(progressEvery) == 0 || n == int64(len(assignments)) {
+					c.Logger.Info().Msgf("proved %v/%v %v assignments in %v, %v failed",
+						n, len(assignments), c.ComponentName, time.Since(start).Round(time.Millisecond), failed.Load())
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+	return results, nil
+}
@@ -0,0 +1,66 @@
+package operations
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProveBatch(t *testing.T) {
+	dir := t.TempDir()
+	err := SetupCubicCircuit(dir)
+	assert.NoError(t, err)
+	instance := NewCircuitOperations(NewCubicConfig(dir, "", dir), "cubic")
+
+	_, err = instance.ProveBatch(context.Background(), nil, BatchOptions{})
+	assert.Error(t, err)
+
+	err = instance.LoadCcsPkVk()
+	assert.NoError(t, err)
+
+	var assignments []frontend.Circuit
+	for x := 0; x < 7; x++ {
+		y := x*x*x + 2*x + 5
+		if x%3 == 2 {
+			y++
+		}
+		assignment, _ := NewCubicCircuitAssignment(x, y)
+		assignments = append(assignments, assignment)
+	}
+	results, err := instance.ProveBatch(context.Background(), assignments, BatchOptions{Workers: 3, IsFront: true, ProgressEvery: 2})
+	assert.NoError(t, err)
+	seen := make(map[int]bool)
+	for result := range results {
+		assert.False(t, seen[result.Index])
+		seen[result.Index] = true
+		if result.Index%3 == 2 {
+			assert.Error(t, result.Err)
+			continue
+		}
+		assert.NoError(t, result.Err)
+		err = PlonkVerify(instance.VerifyingKey, result.Proof.Proof, result.Proof.Witness, true)
+		assert.NoError(t, err)
+	}
+	assert.Len(t, seen, len(assignments))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	results, err = instance.ProveBatch(ctx, assignments, BatchOptions{Workers: 2})
+	assert.NoError(t, err)
+	count := 0
+	for result := range results {
+		count++
+		var canceled *CanceledError
+		assert.True(t, errors.As(result.Err, &canceled))
+		assert.ErrorIs(t, result.Err, context.Canceled)
+	}
+	assert.Equal(t, len(assignments), count)
+
+	results, err = instance.ProveBatch(context.Background(), nil, BatchOptions{})
+	assert.NoError(t, err)
+	_, ok := <-results
+	assert.False(t, ok)
+}
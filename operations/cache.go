@@ -0,0 +1,106 @@
+package operations
+
+import (
+	"context"
+	"errors"
+
+	"github.com/consensys/gnark/constraint"
+)
+
+var ErrCacheClosed = errors.New("cache closed")
+
+// Cache holds loaded artifacts keyed by file path, it is consulted by LoadCcsPkVk.
+// LruManager is the default implementation, see InitLru and WithCache.
+type Cache interface {
+	GetPk(path string) (ProvingKey, error)
+	GetVk(path string) (VerifyingKey, error)
+	GetCcs(path string) (constraint.ConstraintSystem, error)
+	// Invalidate drops path, LoadCcsPkVk reads it again next time
+	Invalidate(path string)
+	// Reset drops every cached value
+	Reset()
+	// Close drops every cached value, later calls to Get* fail with ErrCacheClosed
+	Close() error
+}
+
+// readerCache is implemented by caches which can load through the reader of the caller, so that
+// the Config (curve, raw keys) and context are honoured on a miss. Other caches use their own Get*.
+// read uses ctx, the cache only uses it to stop waiting for a read shared with other callers.
+type readerCache interface {
+	getPk(ctx context.Context, path string, read func(string) (ProvingKey, error)) (ProvingKey, error)
+	getVk(ctx context.Context, path string, read func(string) (VerifyingKey, error)) (VerifyingKey, error)
+	getCcs(ctx context.Context, path string, read func(string) (constraint.ConstraintSystem, error)) (constraint.ConstraintSystem, error)
+}
+
+type Option func(*CircuitOperations)
+
+// WithCache makes LoadCcsPkVk use cache instead of the one set by InitLru, nil disables caching.
+func WithCache(cache Cache) Option {
+	return func(c *CircuitOperations) {
+		c.cache = cache
+		c.cacheSet = true
+	}
+}
+
+func (c *CircuitOperations) getCache() Cache {
+	if c.cacheSet {
+		return c.cache
+	}
+	if lruManager != nil {
+		return lruManager
+	}
+	return nil
+}
+
+// invalidateCache is called once the artifacts of c are written, so that no stale copy is served
+func (c *CircuitOperations) invalidateCache() {
+	cache := c.getCache()
+	if cache == nil {
+		return
+	}
+	cache.Invalidate(c.Config.CcsFile)
+	cache.Invalidate(c.Config.PkFile)
+	cache.Invalidate(c.Config.VkFile)
+}
+
+func (c *CircuitOperations) loadCcs(ctx context.Context) (constraint.ConstraintSystem, error) {
+	read := func(fn string) (constraint.ConstraintSystem, error) {
+		return c.Config.readCcs(ctx, fn)
+	}
+	switch cache := c.getCache().(type) {
+	case nil:
+		return read(c.Config.CcsFile)
+	case readerCache:
+		return cache.getCcs(ctx, c.Config.CcsFile, read)
+	default:
+		return cache.GetCcs(c.Config.CcsFile)
+	}
+}
+
+func (c *CircuitOperations) loadPk(ctx context.Context) (ProvingKey, error) {
+	read := func(fn string) (ProvingKey, error) {
+		return c.Config.readPk(ctx, fn)
+	}
+	switch cache := c.getCache().(type) {
+	case nil:
+		return read(c.Config.PkFile)
+	case readerCache:
+		return cache.getPk(ctx, c.Config.PkFile, read)
+	default:
+		return cache.GetPk(c.Config.PkFile)
+	}
+}
+
+func (c *CircuitOperations) loadVk(ctx context.Context) (VerifyingKey, error) {
+	read := func(fn string) (VerifyingKey, error) {
+		return c.Config.readVk(ctx, fn)
+	}
+	switch cache := c.getCache().(type) {
+	case nil:
+		return read(c.Config.VkFile)
+	case readerCache:
+		return cache.getVk(ctx, c.Config.VkFile, read)
+	default:
+		return cache.GetVk(c.Config.VkFile)
+	}
+}
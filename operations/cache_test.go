@@ -0,0 +1,128 @@
+package operations
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/constraint"
+	"github.com/stretchr/testify/assert"
+)
+
+// countingCache is a Cache without the readerCache fast path
+type countingCache struct {
+	manager *LruManager
+	gets    int
+}
+
+func (c *countingCache) GetPk(path string) (ProvingKey, error) {
+	c.gets++
+	return c.manager.GetPk(path)
+}
+
+func (c *countingCache) GetVk(path string) (VerifyingKey, error) {
+	c.gets++
+	return c.manager.GetVk(path)
+}
+
+func (c *countingCache) GetCcs(path string) (constraint.ConstraintSystem, error) {
+	c.gets++
+	return c.manager.GetCcs(path)
+}
+
+func (c *countingCache) Invalidate(path string) {
+	c.manager.Invalidate(path)
+}
+
+func (c *countingCache) Reset() {
+	c.manager.Reset()
+}
+
+func (c *countingCache) Close() error {
+	return c.manager.Close()
+}
+
+func TestWithCache(t *testing.T) {
+	dir := t.TempDir()
+	err := SetupCubicCircuit(dir)
+	assert.NoError(t, err)
+	config := NewCubicConfig(dir, "", dir)
+	pkFile := filepath.Join(dir, cubicPkFile)
+
+	first, second := NewLruManager(3), NewLruManager(3)
+	err = NewCircuitOperations(config, "cubic", WithCache(first)).LoadCcsPkVk()
+	assert.NoError(t, err)
+	_, ok := first.pkQueue.Peek(pkFile)
+	assert.True(t, ok)
+	_, ok = second.pkQueue.Peek(pkFile)
+	assert.False(t, ok, "caches are isolated")
+
+	// WithCache(nil) ignores the default cache
+	InitLru(3)
+	defer CloseLru()
+	err = NewCircuitOperations(config, "cubic", WithCache(nil)).LoadCcsPkVk()
+	assert.NoError(t, err)
+	_, ok = lruManager.pkQueue.Peek(pkFile)
+	assert.False(t, ok)
+
+	counting := &countingCache{manager: second}
+	instance := NewCircuitOperations(config, "cubic", WithCache(counting))
+	err = instance.LoadCcsPkVk()
+	assert.NoError(t, err)
+	assert.Equal(t, 3, counting.gets)
+	assignment, _ := NewCubicCircuitAssignment(3, 38)
+	_, err = instance.ProveWithAssignment(assignment, true)
+	assert.NoError(t, err)
+
+	first.Reset()
+	_, ok = first.pkQueue.Peek(pkFile)
+	assert.False(t, ok)
+	err = NewCircuitOperations(config, "cubic", WithCache(first)).LoadCcsPkVk()
+	assert.NoError(t, err)
+
+	assert.NoError(t, first.Close())
+	_, ok = first.pkQueue.Peek(pkFile)
+	assert.False(t, ok)
+	err = NewCircuitOperations(config, "cubic", WithCache(first)).LoadCcsPkVk()
+	assert.ErrorIs(t, err, ErrCacheClosed)
+}
+
+func TestLruManager_Invalidation(t *testing.T) {
+	dir := t.TempDir()
+	writeUnsafeSrs(t, dir, ecc.BN254)
+	config := NewCubicConfig(dir, dir, dir)
+	manager := NewLruManager(3)
+	instance := NewCircuitOperations(config, "cubic", WithCache(manager))
+	circuit, _ := NewCubicCircuit()
+	err := instance.SetupAndSaveCcsPkVk(circuit)
+	assert.NoError(t, err)
+
+	vk, err := manager.GetVk(config.VkFile)
+	assert.NoError(t, err)
+	cached, err := manager.GetVk(config.VkFile)
+	assert.NoError(t, err)
+	assert.True(t, vk == cached)
+
+	// a rewritten file is read again on Get
+	err = WriteVk(vk, config.VkFile)
+	assert.NoError(t, err)
+	reloaded, err := manager.GetVk(config.VkFile)
+	assert.NoError(t, err)
+	assert.False(t, vk == reloaded)
+
+	manager.Invalidate(config.VkFile)
+	_, ok := manager.vkQueue.Peek(config.VkFile)
+	assert.False(t, ok)
+
+	// saving the artifacts invalidates them
+	err = instance.LoadCcsPkVk()
+	assert.NoError(t, err)
+	_, ok = manager.pkQueue.Peek(config.PkFile)
+	assert.True(t, ok)
+	err = instance.SetupAndSaveCcsPkVk(circuit)
+	assert.NoError(t, err)
+	for queue, path := range map[*LRUCache[string, *cachedFile]]string{manager.ccsQueue: config.CcsFile, manager.pkQueue: config.PkFile, manager.vkQueue: config.VkFile} {
+		_, ok = queue.Peek(path)
+		assert.False(t, ok, path)
+	}
+}
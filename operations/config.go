@@ -1,5 +1,13 @@
 package operations
 
+import (
+	"context"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/constraint"
+)
+
 type Config struct {
 	CircuitDir string
 	SrsDir     string
@@ -8,4 +16,72 @@ type Config struct {
 	CcsFile string
 	PkFile  string
 	VkFile  string
+
+	// Backend is backend.PLONK (the default) or backend.GROTH16
+	Backend backend.ID
+	// Curve of the circuit, DefaultCurve if not set. Artifacts on another curve are rejected when set
+	Curve ecc.ID
+
+	// RawKeys stores the pk with uncompressed points (WritePkRaw): bigger files, faster loading. The ccs has
+	// no raw encoding and is always written by WriteCcs
+	RawKeys bool
+	// TrustedSource skips subgroup checks when loading raw keys, only set it for locally produced files
+	TrustedSource bool
+
+	// ValidateOnLoad runs ValidateArtifacts at the end of LoadCcsPkVk
+	ValidateOnLoad bool
+	// SkipVerify does not verify the proofs made by ProveWithAssignment and ProveWitness. The prover already
+	// solves the circuit, verifying only guards against prover bugs at the cost of a verification per proof
+	SkipVerify bool
+}
+
+func (cfg *Config) curve() ecc.ID {
+	if cfg.Curve == ecc.UNKNOWN {
+		return DefaultCurve
+	}
+	return cfg.Curve
+}
+
+// proofSystem returns the implementation of Backend, PLONK if not set
+func (cfg *Config) proofSystem() (proofSystem, error) {
+	if cfg.Backend == backend.UNKNOWN {
+		return plonkSystem{}, nil
+	}
+	return proofSystemOf(cfg.Backend)
+}
+
+func (cfg *Config) readPk(ctx context.Context, fn string) (ProvingKey, error) {
+	s, err := cfg.proofSystem()
+	if err != nil {
+		return nil, err
+	}
+	return s.readPk(ctx, fn, cfg.Curve, cfg.RawKeys && cfg.TrustedSource)
+}
+
+func (cfg *Config) readVk(ctx context.Context, fn string) (VerifyingKey, error) {
+	s, err := cfg.proofSystem()
+	if err != nil {
+		return nil, err
+	}
+	return s.readVk(ctx, fn, cfg.Curve)
+}
+
+func (cfg *Config) writePk(pk ProvingKey, fn string) error {
+	s, err := cfg.proofSystem()
+	if err != nil {
+		return err
+	}
+	return s.writePk(pk, fn, cfg.RawKeys)
+}
+
+func (cfg *Config) writeVk(vk VerifyingKey, fn string) error {
+	s, err := cfg.proofSystem()
+	if err != nil {
+		return err
+	}
+	return s.writeVk(vk, fn)
+}
+
+func (cfg *Config) readCcs(ctx context.Context, fn string) (constraint.ConstraintSystem, error) {
+	return ReadCcsCtx(ctx, fn, cfg.Curve)
 }
@@ -0,0 +1,71 @@
+package operations
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+const (
+	PhaseLoad    = "load"
+	PhaseWitness = "witness"
+	PhaseSolve   = "solve"
+	PhaseProve   = "prove"
+	PhaseVerify  = "verify"
+)
+
+// CanceledError is returned by the *Ctx functions when their context is done. Err is the context error,
+// so errors.Is(err, context.Canceled) and errors.Is(err, context.DeadlineExceeded) keep working.
+type CanceledError struct {
+	Phase string
+	Err   error
+}
+
+func (e *CanceledError) Error() string {
+	return fmt.Sprintf("canceled during %v: %v", e.Phase, e.Err)
+}
+
+func (e *CanceledError) Unwrap() error {
+	return e.Err
+}
+
+// runWithContext runs f in its own goroutine and returns as soon as either f or ctx is done.
+// gnark cannot be interrupted, so on cancellation f keeps running and its result is dropped.
+func runWithContext[T any](ctx context.Context, phase string, f func() (T, error)) (T, error) {
+	var zero T
+	err := ctx.Err()
+	if err != nil {
+		return zero, &CanceledError{Phase: phase, Err: err}
+	}
+
+	type result struct {
+		value T
+		err   error
+	}
+	done := make(chan result, 1) // buffered, an abandoned f must not block forever
+	go func() {
+		value, err := f()
+		done <- result{value, err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		return zero, &CanceledError{Phase: phase, Err: ctx.Err()}
+	case r := <-done:
+		return r.value, r.err
+	}
+}
+
+// ctxReader fails reads once ctx is done
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c *ctxReader) Read(p []byte) (int, error) {
+	err := c.ctx.Err()
+	if err != nil {
+		return 0, err
+	}
+	return c.r.Read(p)
+}
@@ -0,0 +1,119 @@
+package operations
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/consensys/gnark/constraint/solver"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/test/unsafekzg"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	blockingHintStarted = make(chan struct{}, 1)
+	blockingHintRelease = make(chan struct{})
+)
+
+// blockingHint copies its input once released, so that a prove can be held mid-way
+func blockingHint(_ *big.Int, inputs []*big.Int, outputs []*big.Int) error {
+	blockingHintStarted <- struct{}{}
+	<-blockingHintRelease
+	outputs[0].Set(inputs[0])
+	return nil
+}
+
+func init() {
+	solver.RegisterHint(blockingHint)
+}
+
+type BlockingCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+func (c *BlockingCircuit) Define(api frontend.API) error {
+	res, err := api.Compiler().NewHint(blockingHint, 1, c.X)
+	if err != nil {
+		return err
+	}
+	api.AssertIsEqual(res[0], c.Y)
+	return nil
+}
+
+func TestPlonkProveCtx_Abandon(t *testing.T) {
+	ccs, err := NewConstraintSystem(&BlockingCircuit{})
+	assert.NoError(t, err)
+	srs, lsrs, err := unsafekzg.NewSRS(ccs, unsafekzg.WithToxicSeed(toxicValue))
+	assert.NoError(t, err)
+	pk, _, err := PlonkSetup(ccs, &srs, &lsrs)
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	result := make(chan error, 1)
+	go func() {
+		_, _, err := PlonkProveCtx(ctx, ccs, pk, &BlockingCircuit{X: 7, Y: 7}, true)
+		result <- err
+	}()
+
+	<-blockingHintStarted
+	cancel()
+
+	select {
+	case err = <-result:
+	case <-time.After(10 * time.Second):
+		t.Fatal("PlonkProveCtx did not return after cancellation")
+	}
+	var canceled *CanceledError
+	assert.ErrorAs(t, err, &canceled)
+	assert.Equal(t, PhaseProve, canceled.Phase)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	// let the abandoned prover finish
+	close(blockingHintRelease)
+}
+
+func TestProveWithAssignmentCtx(t *testing.T) {
+	dir := t.TempDir()
+	err := SetupCubicCircuit(dir)
+	assert.NoError(t, err)
+
+	instance := NewCubic(NewCubicConfig(dir, "", dir))
+	assert.NoError(t, instance.LoadCcsPkVkCtx(context.Background()))
+	assignment, _ := NewCubicCircuitAssignment(3, 38)
+
+	proof, err := instance.ProveWithAssignmentCtx(context.Background(), assignment, true)
+	assert.NoError(t, err)
+	assert.NotNil(t, proof)
+
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	_, err = instance.ProveWithAssignmentCtx(ctx, assignment, true)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	var canceled *CanceledError
+	assert.True(t, errors.As(err, &canceled))
+	assert.Equal(t, PhaseWitness, canceled.Phase)
+}
+
+func TestLoadCcsPkVkCtx_Canceled(t *testing.T) {
+	dir := t.TempDir()
+	err := SetupCubicCircuit(dir)
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	instance := NewCubic(NewCubicConfig(dir, "", dir))
+	err = instance.LoadCcsPkVkCtx(ctx)
+	var canceled *CanceledError
+	assert.ErrorAs(t, err, &canceled)
+	assert.Equal(t, PhaseLoad, canceled.Phase)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	_, err = ReadPkCtx(ctx, filepath.Join(dir, cubicPkFile), DefaultCurve)
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,89 @@
+package operations
+
+import (
+	"fmt"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	kzg_bls12377 "github.com/consensys/gnark-crypto/ecc/bls12-377/kzg"
+	kzg_bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381/kzg"
+	kzg_bn254 "github.com/consensys/gnark-crypto/ecc/bn254/kzg"
+	kzg_bw6761 "github.com/consensys/gnark-crypto/ecc/bw6-761/kzg"
+	"github.com/consensys/gnark-crypto/kzg"
+	native_plonk "github.com/consensys/gnark/backend/plonk"
+	plonk_bls12377 "github.com/consensys/gnark/backend/plonk/bls12-377"
+	plonk_bls12381 "github.com/consensys/gnark/backend/plonk/bls12-381"
+	plonk_bn254 "github.com/consensys/gnark/backend/plonk/bn254"
+	plonk_bw6761 "github.com/consensys/gnark/backend/plonk/bw6-761"
+	"github.com/consensys/gnark/std/algebra/emulated/sw_bn254"
+	"github.com/consensys/gnark/std/algebra/emulated/sw_bw6761"
+	"github.com/lightec-xyz/common/utils"
+)
+
+// DefaultCurve is used when Config.Curve is not set and for legacy headerless artifacts.
+const DefaultCurve = ecc.BN254
+
+func checkCurve(curve ecc.ID) error {
+	switch curve {
+	case ecc.BN254, ecc.BLS12_377, ecc.BLS12_381, ecc.BW6_761:
+		return nil
+	default:
+		return fmt.Errorf("unsupported curve %d", uint16(curve))
+	}
+}
+
+// OuterCurve returns the curve of the circuit recursively verifying proofs on inner: BLS12-377 proofs are
+// verified natively on BW6-761 (2-chain), everything else is verified on BN254.
+func OuterCurve(inner ecc.ID) ecc.ID {
+	if inner == ecc.BLS12_377 {
+		return ecc.BW6_761
+	}
+	return ecc.BN254
+}
+
+// CurveOf returns the curve of a constraint system or of a plonk proving key, verifying key or proof.
+func CurveOf(v interface{}) (ecc.ID, error) {
+	switch r := v.(type) {
+	case interface{ CurveID() ecc.ID }:
+		return r.CurveID(), nil
+	case *plonk_bn254.ProvingKey, *plonk_bn254.VerifyingKey, *plonk_bn254.Proof:
+		return ecc.BN254, nil
+	case *plonk_bls12377.ProvingKey, *plonk_bls12377.VerifyingKey, *plonk_bls12377.Proof:
+		return ecc.BLS12_377, nil
+	case *plonk_bls12381.ProvingKey, *plonk_bls12381.VerifyingKey, *plonk_bls12381.Proof:
+		return ecc.BLS12_381, nil
+	case *plonk_bw6761.ProvingKey, *plonk_bw6761.VerifyingKey, *plonk_bw6761.Proof:
+		return ecc.BW6_761, nil
+	default:
+		return ecc.UNKNOWN, fmt.Errorf("unknown curve for %T", v)
+	}
+}
+
+// UnsafeFingerPrintFromVk computes the fingerprint of vk in the scalar field of its OuterCurve,
+// i.e. the value the recursive verifier circuit sees.
+func UnsafeFingerPrintFromVk(vk native_plonk.VerifyingKey) (utils.FingerPrintBytes, error) {
+	curve, err := CurveOf(vk)
+	if err != nil {
+		return nil, err
+	}
+	switch OuterCurve(curve) {
+	case ecc.BW6_761:
+		return utils.UnsafeFingerPrintFromVk[sw_bw6761.ScalarField](vk)
+	default:
+		return utils.UnsafeFingerPrintFromVk[sw_bn254.ScalarField](vk)
+	}
+}
+
+func srsG1Len(srs kzg.SRS) (int, error) {
+	switch r := srs.(type) {
+	case *kzg_bn254.SRS:
+		return len(r.Pk.G1), nil
+	case *kzg_bls12377.SRS:
+		return len(r.Pk.G1), nil
+	case *kzg_bls12381.SRS:
+		return len(r.Pk.G1), nil
+	case *kzg_bw6761.SRS:
+		return len(r.Pk.G1), nil
+	default:
+		return 0, fmt.Errorf("unknown srs type %T", srs)
+	}
+}
@@ -0,0 +1,78 @@
+package operations
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/test/unsafekzg"
+	"github.com/lightec-xyz/common/artifact"
+	"github.com/stretchr/testify/assert"
+)
+
+// writeUnsafeSrs stores a test srs for the cubic circuit on curve in the layout expected by ReadSrsWithCurve
+func writeUnsafeSrs(t *testing.T, srsDir string, curve ecc.ID) {
+	circuit, _ := NewCubicCircuit()
+	ccs, err := NewConstraintSystemWithCurve(circuit, curve)
+	assert.NoError(t, err)
+
+	srs, lsrs, err := unsafekzg.NewSRS(ccs, unsafekzg.WithToxicSeed(toxicValue))
+	assert.NoError(t, err)
+
+	index := Power2Index(ecc.NextPowerOfTwo(uint64(ccs.GetNbConstraints() + ccs.GetNbPublicVariables())))
+	err = WriteToFileAtomic(srs, filepath.Join(srsDir, fmt.Sprintf("%v_pow_%v.srs", curve, index)))
+	assert.NoError(t, err)
+	err = WriteToFileAtomic(lsrs, filepath.Join(srsDir, fmt.Sprintf("%v_pow_%v.lsrs", curve, index)))
+	assert.NoError(t, err)
+}
+
+func TestMultiCurveLifecycle(t *testing.T) {
+	for _, curve := range []ecc.ID{ecc.BN254, ecc.BLS12_377, ecc.BLS12_381, ecc.BW6_761} {
+		t.Run(curve.String(), func(t *testing.T) {
+			dir := t.TempDir()
+			writeUnsafeSrs(t, dir, curve)
+
+			config := NewCubicConfig(dir, dir, dir)
+			config.Curve = curve
+			setup := NewCircuitOperations(config, "cubic")
+			circuit, _ := NewCubicCircuit()
+			err := setup.SetupAndSaveCcsPkVk(circuit)
+			assert.NoError(t, err)
+
+			// the curve is detected from the artifacts
+			instance := NewCubic(NewCubicConfig(dir, dir, dir))
+			err = instance.Load()
+			assert.NoError(t, err)
+			ccsCurve, err := CurveOf(instance.Ccs)
+			assert.NoError(t, err)
+			assert.Equal(t, curve, ccsCurve)
+
+			assignment, _ := NewCubicCircuitAssignment(3, 38)
+			for _, isFront := range []bool{true, false} {
+				proof, err := instance.ProveWithAssignment(assignment, isFront)
+				assert.NoError(t, err)
+
+				proofFile := filepath.Join(dir, "cubic.proof")
+				witnessFile := filepath.Join(dir, "cubic.wtns")
+				err = SaveProofAndWitness(proof, proofFile, witnessFile)
+				assert.NoError(t, err)
+				read, err := ReadProofAndWitness(proofFile, witnessFile)
+				assert.NoError(t, err)
+				err = instance.Verify(instance.VerifyingKey, read.Proof, read.Witness, isFront)
+				assert.NoError(t, err)
+			}
+
+			fp, err := instance.UnsafeFingerPrint()
+			assert.NoError(t, err)
+			assert.NotEmpty(t, fp)
+
+			other := ecc.BN254
+			if curve == ecc.BN254 {
+				other = ecc.BLS12_381
+			}
+			_, err = ReadPkWithCurve(config.PkFile, other)
+			assert.ErrorIs(t, err, artifact.ErrCurveMismatch)
+		})
+	}
+}
@@ -0,0 +1,216 @@
+package operations
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const diskCacheIndex = "index.json"
+
+// DiskCache keeps local copies of artifact files in a directory, so that a file evicted from memory is
+// read again from local disk rather than from its, possibly slow, original location. Copies are named
+// by the sha256 of their content, so identical files are stored once, and the least recently used ones
+// are removed once their total size exceeds quota (0 for no quota).
+type DiskCache struct {
+	dir   string
+	files *LRUCache[string, int64] // content hash -> size
+
+	lock  sync.Mutex
+	index map[string]diskCacheEntry // source path -> copy
+}
+
+// diskCacheEntry identifies the version of the source file a copy was made from
+type diskCacheEntry struct {
+	Hash    string    `json:"hash"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modTime"`
+}
+
+// NewDiskCache opens or creates the cache in dir, taking over the copies left by a previous process.
+func NewDiskCache(dir string, quota int64) (*DiskCache, error) {
+	err := os.MkdirAll(dir, os.ModePerm)
+	if err != nil {
+		return nil, err
+	}
+	d := &DiskCache{
+		dir:   dir,
+		files: NewSizedLRUCache[string, int64](quota),
+		index: make(map[string]diskCacheEntry),
+	}
+	d.files.OnEvict(func(hash string, _ int64) {
+		_ = os.Remove(filepath.Join(d.dir, hash))
+	})
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var copies []fs.FileInfo
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasPrefix(name, ".") && strings.Contains(name, ".tmp-") {
+			_ = os.Remove(filepath.Join(dir, name)) // interrupted copy
+			continue
+		}
+		if !isContentHash(name) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		copies = append(copies, info)
+	}
+	// least recently used first, so that they end up at the back of the LRU
+	sort.Slice(copies, func(i, j int) bool {
+		return copies[i].ModTime().Before(copies[j].ModTime())
+	})
+	for _, info := range copies {
+		d.files.PutWithSize(info.Name(), info.Size(), info.Size())
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, diskCacheIndex))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	if err == nil {
+		err = json.Unmarshal(data, &d.index)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for path, entry := range d.index {
+		if _, ok := d.files.Peek(entry.Hash); !ok {
+			delete(d.index, path)
+		}
+	}
+	return d, nil
+}
+
+// Local returns the path of a local copy of path, copying it first if needed. path itself is
+// returned when it is larger than the quota.
+func (d *DiskCache) Local(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	path, err = filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+
+	d.lock.Lock()
+	entry, ok := d.index[path]
+	d.lock.Unlock()
+	if ok && entry.Size == info.Size() && entry.ModTime.Equal(info.ModTime()) {
+		if _, ok := d.files.Get(entry.Hash); ok {
+			local := filepath.Join(d.dir, entry.Hash)
+			now := time.Now()
+			_ = os.Chtimes(local, now, now) // keep the recency across restarts
+			return local, nil
+		}
+	}
+
+	hash, err := d.copy(path)
+	if err != nil {
+		return "", err
+	}
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if _, ok := d.files.Get(hash); !ok {
+		d.files.PutWithSize(hash, info.Size(), info.Size())
+		if _, ok := d.files.Peek(hash); !ok {
+			// over quota
+			_ = os.Remove(filepath.Join(d.dir, hash))
+			return path, nil
+		}
+	}
+	d.index[path] = diskCacheEntry{Hash: hash, Size: info.Size(), ModTime: info.ModTime()}
+	err = d.saveIndex()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(d.dir, hash), nil
+}
+
+// copy stores the content of path in the cache directory and returns its hash
+func (d *DiskCache) copy(path string) (hash string, err error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = src.Close()
+	}()
+
+	tmp, err := os.CreateTemp(d.dir, ".copy.tmp-*")
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		if err != nil {
+			_ = tmp.Close()
+			_ = os.Remove(tmp.Name())
+		}
+	}()
+
+	h := sha256.New()
+	_, err = io.Copy(io.MultiWriter(tmp, h), src)
+	if err != nil {
+		return "", err
+	}
+	err = tmp.Sync()
+	if err != nil {
+		return "", err
+	}
+	err = tmp.Close()
+	if err != nil {
+		return "", err
+	}
+
+	hash = hex.EncodeToString(h.Sum(nil))
+	err = os.Rename(tmp.Name(), filepath.Join(d.dir, hash))
+	if err != nil {
+		return "", err
+	}
+	return hash, nil
+}
+
+func (d *DiskCache) saveIndex() error {
+	for path, entry := range d.index {
+		if _, ok := d.files.Peek(entry.Hash); !ok {
+			delete(d.index, path)
+		}
+	}
+	data, err := json.Marshal(d.index)
+	if err != nil {
+		return err
+	}
+	return WriteFileAtomic(filepath.Join(d.dir, diskCacheIndex), func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
+
+func (d *DiskCache) Stats() CacheStats {
+	return d.files.Stats()
+}
+
+func isContentHash(name string) bool {
+	if len(name) != 2*sha256.Size {
+		return false
+	}
+	_, err := hex.DecodeString(name)
+	return err == nil
+}
@@ -0,0 +1,84 @@
+package operations
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiskCache(t *testing.T) {
+	srcDir, cacheDir := t.TempDir(), t.TempDir()
+	write := func(name, content string) string {
+		fn := filepath.Join(srcDir, name)
+		assert.NoError(t, os.WriteFile(fn, []byte(content), 0644))
+		return fn
+	}
+	a := write("a", "aaaaaa")
+	b := write("b", "aaaaaa")
+	c := write("c", "cccccc")
+	big := write("big", "0123456789+")
+
+	disk, err := NewDiskCache(cacheDir, 10)
+	assert.NoError(t, err)
+
+	localA, err := disk.Local(a)
+	assert.NoError(t, err)
+	assert.Equal(t, cacheDir, filepath.Dir(localA))
+	content, err := os.ReadFile(localA)
+	assert.NoError(t, err)
+	assert.Equal(t, "aaaaaa", string(content))
+
+	// same content, same copy
+	localB, err := disk.Local(b)
+	assert.NoError(t, err)
+	assert.Equal(t, localA, localB)
+
+	// larger than the quota, read in place
+	local, err := disk.Local(big)
+	assert.NoError(t, err)
+	assert.Equal(t, big, local)
+
+	// c does not fit next to a, which is evicted
+	localC, err := disk.Local(c)
+	assert.NoError(t, err)
+	assert.NoFileExists(t, localA)
+	assert.FileExists(t, localC)
+	assert.Equal(t, 1, disk.Stats().Len)
+	assert.Equal(t, uint64(1), disk.Stats().Evictions)
+
+	// a changed source is copied again
+	write("c", "dddddd")
+	localD, err := disk.Local(c)
+	assert.NoError(t, err)
+	assert.NotEqual(t, localC, localD)
+
+	// the copies and the index survive a restart
+	disk, err = NewDiskCache(cacheDir, 10)
+	assert.NoError(t, err)
+	local, err = disk.Local(c)
+	assert.NoError(t, err)
+	assert.Equal(t, localD, local)
+	assert.Equal(t, uint64(1), disk.Stats().Hits)
+}
+
+func TestLruManager_DiskCache(t *testing.T) {
+	dir := t.TempDir()
+	err := SetupCubicCircuit(dir)
+	assert.NoError(t, err)
+	pkFile := filepath.Join(dir, cubicPkFile)
+
+	disk, err := NewDiskCache(filepath.Join(dir, "cache"), 0)
+	assert.NoError(t, err)
+	manager := NewLruManager(1, WithDiskCache(disk))
+	_, err = manager.GetPk(pkFile)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, disk.Stats().Len)
+
+	// reloaded from the local copy after a memory eviction
+	manager.Reset()
+	_, err = manager.GetPk(pkFile)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), disk.Stats().Hits)
+}
@@ -0,0 +1,134 @@
+package operations
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"io"
+	"io/fs"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm/runtime"
+	"github.com/stretchr/testify/assert"
+)
+
+// The verifier of the cubic circuit set up by SetupCubicCircuit is pinned in testdata: cubic_verifier.sol as
+// exported by WriteVkInSolidity and PlonkVerifier.bin, its creation bytecode compiled by
+//
+//	solc-0.8.26 --optimize --bin --overwrite -o testdata testdata/cubic_verifier.sol
+//
+// TestSolidityVerifierEVM fails once a gnark upgrade changes the exported verifier: check the changes, then run
+// go test -run TestSolidityVerifierEVM -update-verifier and recompile the bytecode as above.
+var updateVerifier = flag.Bool("update-verifier", false, "rewrite testdata/cubic_verifier.sol")
+
+const (
+	pinnedVerifierSol = "testdata/cubic_verifier.sol"
+	pinnedVerifierBin = "testdata/PlonkVerifier.bin"
+)
+
+// evm runs contracts in an in-memory go-ethereum EVM with every fork, thus the bn254 precompiles, enabled
+type evm struct {
+	cfg *runtime.Config
+}
+
+func newEvm() *evm {
+	return &evm{cfg: &runtime.Config{}}
+}
+
+// deploy runs the creation bytecode code and returns the address of the contract
+func (e *evm) deploy(code []byte) (common.Address, error) {
+	_, address, _, err := runtime.Create(code, e.cfg)
+	return address, err
+}
+
+func (e *evm) call(address common.Address, calldata []byte) ([]byte, error) {
+	ret, _, err := runtime.Call(address, calldata, e.cfg)
+	return ret, err
+}
+
+// verifies reports whether Verify(bytes,uint256[]) returned true, a revert is reported as false
+func (e *evm) verifies(t *testing.T, address common.Address, calldata []byte) bool {
+	ret, err := e.call(address, calldata)
+	if err != nil {
+		t.Logf("Verify reverted: %v", err)
+		return false
+	}
+	assert.Len(t, ret, 32)
+	return new(big.Int).SetBytes(ret).Cmp(big.NewInt(1)) == 0
+}
+
+func TestEvm(t *testing.T) {
+	// creation code returning the runtime code PUSH1 1 PUSH1 0 MSTORE PUSH1 32 PUSH1 0 RETURN, i.e. return true
+	runtimeCode := "600160005260206000f3"
+	creationCode, err := hex.DecodeString("600a600c600039600a6000f3" + runtimeCode)
+	assert.NoError(t, err)
+
+	e := newEvm()
+	address, err := e.deploy(creationCode)
+	assert.NoError(t, err)
+	assert.Equal(t, runtimeCode, hex.EncodeToString(e.cfg.State.GetCode(address)))
+	assert.True(t, e.verifies(t, address, nil))
+
+	// the verifier relies on the bn254 pairing precompile, true for an empty input
+	assert.True(t, e.verifies(t, common.BytesToAddress([]byte{8}), nil))
+}
+
+func TestSolidityVerifierEVM(t *testing.T) {
+	dir := t.TempDir()
+	err := SetupCubicCircuit(dir)
+	assert.NoError(t, err)
+	instance := NewCubic(NewCubicConfig(dir, "", dir))
+	err = instance.Load()
+	assert.NoError(t, err)
+
+	sol := filepath.Join(dir, "cubic_verifier.sol")
+	err = WriteVkInSolidity(instance.VerifyingKey, sol)
+	assert.NoError(t, err)
+	exported, err := os.ReadFile(sol)
+	assert.NoError(t, err)
+	if *updateVerifier {
+		err = WriteFileAtomic(pinnedVerifierSol, func(w io.Writer) error {
+			_, err := w.Write(exported)
+			return err
+		})
+		assert.NoError(t, err)
+	}
+	pinned, err := os.ReadFile(pinnedVerifierSol)
+	assert.NoError(t, err)
+	if !bytes.Equal(exported, pinned) {
+		t.Fatalf("the exported verifier differs from %v, see the comment of updateVerifier", pinnedVerifierSol)
+	}
+
+	bin, err := os.ReadFile(pinnedVerifierBin)
+	if errors.Is(err, fs.ErrNotExist) {
+		t.Skipf("%v not compiled, see the comment of updateVerifier", pinnedVerifierBin)
+	}
+	assert.NoError(t, err)
+	code, err := hex.DecodeString(strings.TrimSpace(string(bin)))
+	assert.NoError(t, err)
+
+	e := newEvm()
+	address, err := e.deploy(code)
+	assert.NoError(t, err)
+
+	assignment, _ := NewCubicCircuitAssignment(3, 38)
+	proof, err := instance.ProveWithAssignment(assignment, true)
+	assert.NoError(t, err)
+	bundle, err := NewSolidityBundle(proof, instance.VerifyingKey)
+	assert.NoError(t, err)
+	calldata, err := parseHexBytes(bundle.Calldata)
+	assert.NoError(t, err)
+	assert.True(t, e.verifies(t, address, calldata))
+
+	solidityProof, err := parseHexBytes(bundle.Proof)
+	assert.NoError(t, err)
+	assert.False(t, e.verifies(t, address, encodeSolidityVerify(solidityProof, [][32]byte{{31: 39}})))
+	solidityProof[len(solidityProof)-1] ^= 1
+	assert.False(t, e.verifies(t, address, encodeSolidityVerify(solidityProof, [][32]byte{{31: 38}})))
+}
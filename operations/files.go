@@ -1,32 +1,46 @@
 package operations
 
 import (
+	"bufio"
+	"bytes"
+	"context"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"math/bits"
 	"os"
 	"path/filepath"
 
 	"github.com/consensys/gnark-crypto/ecc"
-	kzg_bn254 "github.com/consensys/gnark-crypto/ecc/bn254/kzg"
 	"github.com/consensys/gnark-crypto/kzg"
+	"github.com/consensys/gnark/backend/groth16"
 	"github.com/consensys/gnark/backend/plonk"
-	plonk_bn254 "github.com/consensys/gnark/backend/plonk/bn254"
 	"github.com/consensys/gnark/backend/witness"
 	"github.com/consensys/gnark/constraint"
-	cs_bn254 "github.com/consensys/gnark/constraint/bn254"
+	gnarkio "github.com/consensys/gnark/io"
+	"github.com/lightec-xyz/common/artifact"
 )
 
+// ReadPk reads a pk on the curve recorded in its header, legacy files are read as DefaultCurve.
 func ReadPk(fn string) (plonk.ProvingKey, error) {
-	f, err := os.Open(fn)
+	return ReadPkWithCurve(fn, ecc.UNKNOWN)
+}
+
+// ReadPkWithCurve reads a pk on curve, ecc.UNKNOWN means detecting the curve as ReadPk does.
+func ReadPkWithCurve(fn string, curve ecc.ID) (plonk.ProvingKey, error) {
+	return ReadPkCtx(context.Background(), fn, curve)
+}
+
+// ReadPkCtx is ReadPkWithCurve aborting with a *CanceledError once ctx is done.
+func ReadPkCtx(ctx context.Context, fn string, curve ecc.ID) (plonk.ProvingKey, error) {
+	curve, err := ResolveCurve(fn, curve)
 	if err != nil {
 		return nil, err
 	}
-	defer func() {
-		_ = f.Close()
-	}()
-	pk := plonk.NewProvingKey(ecc.BN254)
-	_, err = pk.ReadFrom(f)
+	pk := plonk.NewProvingKey(curve)
+	_, err = ReadArtifactCtx(ctx, fn, artifact.KindPk, curve, pk.ReadFrom)
 	if err != nil {
 		return nil, err
 	}
@@ -34,70 +48,87 @@ func ReadPk(fn string) (plonk.ProvingKey, error) {
 }
 
 func WritePk(pk plonk.ProvingKey, fn string) error {
-	openFile, err := OpenFileOnCreaterOverwrite(fn)
-	if err != nil {
-		return err
-	}
-	defer func() {
-		_ = openFile.Close()
-	}()
+	return WriteArtifact(fn, artifact.KindPk, pk, nil)
+}
 
-	_, err = pk.WriteTo(openFile)
-	if err != nil {
-		return err
-	}
+// ReadPkRaw reads a pk written by WritePkRaw, or any pk file. Subgroup checks are skipped if trusted is set,
+// which is only safe for files produced locally.
+func ReadPkRaw(fn string, trusted bool) (plonk.ProvingKey, error) {
+	return ReadPkRawWithCurve(fn, ecc.UNKNOWN, trusted)
+}
 
-	return nil
+func ReadPkRawWithCurve(fn string, curve ecc.ID, trusted bool) (plonk.ProvingKey, error) {
+	return ReadPkRawCtx(context.Background(), fn, curve, trusted)
 }
 
-func ReadVk(fn string) (plonk.VerifyingKey, error) {
-	f, err := os.Open(fn)
+func ReadPkRawCtx(ctx context.Context, fn string, curve ecc.ID, trusted bool) (plonk.ProvingKey, error) {
+	curve, err := ResolveCurve(fn, curve)
 	if err != nil {
 		return nil, err
 	}
-	defer func() {
-		_ = f.Close()
-	}()
-	vk := plonk.NewVerifyingKey(ecc.BN254)
-	_, err = vk.ReadFrom(f)
+	pk := plonk.NewProvingKey(curve)
+	read := pk.ReadFrom
+	if trusted {
+		read = pk.UnsafeReadFrom
+	}
+	_, err = ReadArtifactCtx(ctx, fn, artifact.KindPk, curve, read)
 	if err != nil {
 		return nil, err
 	}
+	return pk, nil
+}
 
-	return vk, nil
+// WritePkRaw writes pk with uncompressed points: the file is larger but loads much faster.
+func WritePkRaw(pk plonk.ProvingKey, fn string) error {
+	return WriteArtifact(fn, artifact.KindPk, rawWriter{pk}, nil)
 }
 
-func WriteVk(vk plonk.VerifyingKey, fn string) error {
-	openFile, err := OpenFileOnCreaterOverwrite(fn)
+func ReadVk(fn string) (plonk.VerifyingKey, error) {
+	return ReadVkWithCurve(fn, ecc.UNKNOWN)
+}
+
+func ReadVkWithCurve(fn string, curve ecc.ID) (plonk.VerifyingKey, error) {
+	return ReadVkCtx(context.Background(), fn, curve)
+}
+
+func ReadVkCtx(ctx context.Context, fn string, curve ecc.ID) (plonk.VerifyingKey, error) {
+	curve, err := ResolveCurve(fn, curve)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer func() {
-		_ = openFile.Close()
-	}()
-
-	_, err = vk.WriteTo(openFile)
+	vk := plonk.NewVerifyingKey(curve)
+	hdr, err := ReadArtifactCtx(ctx, fn, artifact.KindVk, curve, vk.ReadFrom)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
+	if hdr != nil && len(hdr.FingerPrint) > 0 {
+		fp, err := UnsafeFingerPrintFromVk(vk)
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(fp, hdr.FingerPrint) {
+			return nil, fmt.Errorf("vk fingerprint mismatch: header %x, computed %x", hdr.FingerPrint, fp)
+		}
+	}
+
+	return vk, nil
 }
 
-func WriteVkInSolidity(vk plonk.VerifyingKey, fn string) error {
-	openFile, err := OpenFileOnCreaterOverwrite(fn)
+// WriteVk also records the vk fingerprint (as used by CircuitOperations.UnsafeFingerPrint) in the header when it can be computed.
+func WriteVk(vk plonk.VerifyingKey, fn string) error {
+	fp, err := UnsafeFingerPrintFromVk(vk)
 	if err != nil {
-		return err
+		fp = nil
 	}
-	defer func() {
-		_ = openFile.Close()
-	}()
+	return WriteArtifact(fn, artifact.KindVk, vk, fp)
+}
 
-	err = vk.ExportSolidity(openFile)
-	if err != nil {
-		return err
-	}
-	return nil
+// WriteVkInSolidity exports the verifier contract of a bn254 PLONK or Groth16 vk
+func WriteVkInSolidity(vk VerifyingKey, fn string) error {
+	return WriteFileAtomic(fn, func(w io.Writer) error {
+		return vk.ExportSolidity(w)
+	})
 }
 
 func ReadCcsAndVk(ccsFile, vkFile string) (constraint.ConstraintSystem, plonk.VerifyingKey, error) {
@@ -115,98 +146,212 @@ func ReadCcsAndVk(ccsFile, vkFile string) (constraint.ConstraintSystem, plonk.Ve
 }
 
 func ReadCcs(fn string) (constraint.ConstraintSystem, error) {
-	var ccs cs_bn254.SparseR1CS
-	f, err := os.Open(fn)
+	return ReadCcsWithCurve(fn, ecc.UNKNOWN)
+}
+
+func ReadCcsWithCurve(fn string, curve ecc.ID) (constraint.ConstraintSystem, error) {
+	return ReadCcsCtx(context.Background(), fn, curve)
+}
+
+func ReadCcsCtx(ctx context.Context, fn string, curve ecc.ID) (constraint.ConstraintSystem, error) {
+	curve, err := ResolveCurve(fn, curve)
 	if err != nil {
 		return nil, err
 	}
-	defer func() {
-		_ = f.Close()
-	}()
-	_, err = ccs.ReadFrom(f)
+	kind := resolveKind(fn, artifact.KindCcs, artifact.KindR1cs)
+	ccs := newCS(kind, curve)
+	_, err = ReadArtifactCtx(ctx, fn, kind, curve, ccs.ReadFrom)
 	if err != nil {
 		return nil, err
 	}
 
-	return &ccs, nil
+	return ccs, nil
 }
 
+// WriteCcs writes a SparseR1CS for PLONK or an R1CS for Groth16. gnark has a single encoding for constraint
+// systems, so unlike keys they have no raw variant.
 func WriteCcs(ccs constraint.ConstraintSystem, fn string) error {
-	openFile, err := OpenFileOnCreaterOverwrite(fn)
-	if err != nil {
-		return err
+	return WriteArtifact(fn, ccsKind(ccs), ccs, nil)
+}
+
+func ccsKind(ccs constraint.ConstraintSystem) artifact.Kind {
+	if _, ok := ccs.(constraint.R1CS[constraint.U64]); ok {
+		return artifact.KindR1cs
 	}
-	defer func() {
-		_ = openFile.Close()
-	}()
+	return artifact.KindCcs
+}
 
-	_, err = ccs.WriteTo(openFile)
-	if err != nil {
-		return err
+func newCS(kind artifact.Kind, curve ecc.ID) constraint.ConstraintSystem {
+	if kind == artifact.KindR1cs {
+		return groth16.NewCS(curve)
 	}
+	return plonk.NewCS(curve)
+}
 
-	return nil
+// rawWriter serializes with WriteRawTo when available. It keeps the curve of the wrapped object.
+type rawWriter struct {
+	src io.WriterTo
 }
 
-func ReadProof(fn string) (plonk.Proof, error) {
-	var bn254Proof plonk_bn254.Proof
-	f, err := os.Open(fn)
+func (r rawWriter) WriteTo(w io.Writer) (int64, error) {
+	if raw, ok := r.src.(gnarkio.WriterRawTo); ok {
+		return raw.WriteRawTo(w)
+	}
+	return r.src.WriteTo(w)
+}
+
+func (r rawWriter) CurveID() ecc.ID {
+	curve, _ := CurveOf(r.src)
+	return curve
+}
+
+// ReadProof reads a PLONK proof, or a groth16.Proof if the header says so.
+func ReadProof(fn string) (SnarkProof, error) {
+	return ReadProofWithCurve(fn, ecc.UNKNOWN)
+}
+
+func ReadProofWithCurve(fn string, curve ecc.ID) (SnarkProof, error) {
+	curve, err := ResolveCurve(fn, curve)
 	if err != nil {
 		return nil, err
 	}
-	defer func() {
-		_ = f.Close()
-	}()
-	_, err = bn254Proof.ReadFrom(f)
+	kind := resolveKind(fn, artifact.KindProof, artifact.KindGroth16Proof)
+	var proof SnarkProof
+	if kind == artifact.KindGroth16Proof {
+		proof = groth16.NewProof(curve)
+	} else {
+		proof = plonk.NewProof(curve)
+	}
+	_, err = ReadArtifact(fn, kind, curve, proof.ReadFrom)
 	if err != nil {
 		return nil, err
 	}
-	return &bn254Proof, nil
+	return proof, nil
 }
 
-func WriteProof(proof plonk.Proof, fn string) error {
-	openFile, err := OpenFileOnCreaterOverwrite(fn)
+// WriteProof writes a PLONK proof or a groth16.Proof.
+func WriteProof(proof SnarkProof, fn string) error {
+	kind := artifact.KindProof
+	if _, ok := proof.(groth16.Proof); ok {
+		kind = artifact.KindGroth16Proof
+	}
+	return WriteArtifact(fn, kind, proof, nil)
+}
+
+// ReadArtifact opens fn and hands the payload of the artifact container to payload, after checking kind and curve.
+// Length and SHA-256 of the payload are verified once it has been read. Legacy headerless files are read as is,
+// in which case the returned header is nil.
+func ReadArtifact(fn string, kind artifact.Kind, curve ecc.ID, payload func(r io.Reader) (int64, error)) (*artifact.Header, error) {
+	return ReadArtifactCtx(context.Background(), fn, kind, curve, payload)
+}
+
+// ReadArtifactCtx is ReadArtifact checking ctx on every read from fn, so that loading multi-GB keys
+// stops shortly after ctx is done. It then returns a *CanceledError.
+func ReadArtifactCtx(ctx context.Context, fn string, kind artifact.Kind, curve ecc.ID, payload func(r io.Reader) (int64, error)) (*artifact.Header, error) {
+	err := ctx.Err()
 	if err != nil {
-		return err
+		return nil, &CanceledError{Phase: PhaseLoad, Err: err}
+	}
+	f, err := os.Open(fn)
+	if err != nil {
+		return nil, err
 	}
 	defer func() {
-		_ = openFile.Close()
+		_ = f.Close()
 	}()
+	hdr, err := artifact.Read(&ctxReader{ctx: ctx, r: f}, kind, curve, payload)
+	if ctxErr := ctx.Err(); err != nil && ctxErr != nil {
+		return nil, &CanceledError{Phase: PhaseLoad, Err: ctxErr}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read %v %v: %w", kind, fn, err)
+	}
+	return hdr, nil
+}
 
-	_, err = proof.WriteTo(openFile)
+// ResolveCurve returns curve if it is set, otherwise the curve recorded in the header of fn,
+// or DefaultCurve for legacy headerless files.
+func ResolveCurve(fn string, curve ecc.ID) (ecc.ID, error) {
+	if curve != ecc.UNKNOWN {
+		return curve, checkCurve(curve)
+	}
+	hdr, err := ReadArtifactHeader(fn)
+	if errors.Is(err, artifact.ErrLegacy) {
+		return DefaultCurve, nil
+	}
 	if err != nil {
-		return err
+		return ecc.UNKNOWN, err
 	}
+	return hdr.Curve, checkCurve(hdr.Curve)
+}
 
-	return nil
+// resolveKind returns the kind recorded in the header of fn if it is one of kinds, kinds[0] otherwise.
+func resolveKind(fn string, kinds ...artifact.Kind) artifact.Kind {
+	hdr, err := ReadArtifactHeader(fn)
+	if err == nil {
+		for _, kind := range kinds {
+			if hdr.Kind == kind {
+				return kind
+			}
+		}
+	}
+	return kinds[0]
 }
 
-func WriteProofInSolidity(proof plonk.Proof, fn string) error {
-	openFile, err := OpenFileOnCreaterOverwrite(fn)
+// ReadArtifactHeader returns the container header of fn, or artifact.ErrLegacy for headerless files.
+func ReadArtifactHeader(fn string) (*artifact.Header, error) {
+	f, err := os.Open(fn)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer func() {
-		_ = openFile.Close()
+		_ = f.Close()
 	}()
+	return artifact.ReadHeader(bufio.NewReader(f))
+}
 
-	_proof := proof.(*plonk_bn254.Proof)
-	proofStr := hex.EncodeToString(_proof.MarshalSolidity())
-
-	_, err = openFile.WriteString(proofStr)
+// WriteArtifact atomically writes src to fn wrapped in an artifact container of the given kind.
+// The curve is taken from src, fingerPrint is optional.
+func WriteArtifact(fn string, kind artifact.Kind, src io.WriterTo, fingerPrint []byte) error {
+	curve, err := CurveOf(src)
 	if err != nil {
 		return err
 	}
+	hdr := artifact.NewHeader(kind, curve, fingerPrint)
+	return writeFileAtomic(fn, func(f *os.File) error {
+		return artifact.Write(f, hdr, src.WriteTo)
+	})
+}
 
-	return nil
+// WriteProofInSolidity accepts bn254 PLONK and Groth16 proofs.
+func WriteProofInSolidity(proof SnarkProof, fn string) error {
+	_proof, ok := proof.(interface{ MarshalSolidity() []byte })
+	if !ok {
+		return fmt.Errorf("solidity export is only supported on bn254, got %T", proof)
+	}
+	proofStr := hex.EncodeToString(_proof.MarshalSolidity())
+
+	return WriteFileAtomic(fn, func(w io.Writer) error {
+		_, err := io.WriteString(w, proofStr)
+		return err
+	})
 }
 
+// ReadWitness reads a witness on DefaultCurve. Witness files do not record their field.
 func ReadWitness(fn string) (witness.Witness, error) {
-	field := ecc.BN254.ScalarField()
+	return ReadWitnessWithCurve(fn, DefaultCurve)
+}
+
+func ReadWitnessWithCurve(fn string, curve ecc.ID) (witness.Witness, error) {
+	err := checkCurve(curve)
+	if err != nil {
+		return nil, err
+	}
+	field := curve.ScalarField()
 	var (
 		wit witness.Witness
 	)
-	wit, err := witness.New(field)
+	wit, err = witness.New(field)
 	if err != nil {
 		return nil, err
 	}
@@ -227,49 +372,28 @@ func ReadWitness(fn string) (witness.Witness, error) {
 }
 
 func WriteWitness(wit witness.Witness, fn string) error {
-	openFile, err := OpenFileOnCreaterOverwrite(fn)
-	if err != nil {
-		return err
-	}
-	defer func() {
-		_ = openFile.Close()
-	}()
-
 	//suppose wtns should only have public ones, but if all witness are given, extract only public ones
 	pubWit, err := wit.Public()
 	if err != nil {
 		return err
 	}
 
-	_, err = pubWit.WriteTo(openFile)
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return WriteToFileAtomic(pubWit, fn)
 }
 
+// WriteWitnessInJson writes the public vector of wit as a JSON array of numbers, see WriteWitnessJson for
+// named fields.
 func WriteWitnessInJson(wit witness.Witness, fn string) error {
-	openFile, err := OpenFileOnCreaterOverwrite(fn)
-	if err != nil {
-		return err
-	}
-	defer func() {
-		_ = openFile.Close()
-	}()
-
 	pw, err := wit.Public()
 	if err != nil {
 		return err
 	}
 
 	pwStr := fmt.Sprint(pw.Vector())
-	_, err = openFile.WriteString(pwStr)
-	if err != nil {
+	return WriteFileAtomic(fn, func(w io.Writer) error {
+		_, err := io.WriteString(w, pwStr)
 		return err
-	}
-
-	return nil
+	})
 }
 
 func SaveProofAndWitness(proof *Proof, proofFile, witnessFile string) error {
@@ -300,13 +424,18 @@ func SaveProofAndWitnessInSol(proof *Proof, proofSolFile, witnessSolFile string)
 	return nil
 }
 
+// ReadProofAndWitness reads the witness on the curve of the proof.
 func ReadProofAndWitness(proofFile, pubWitnessFile string) (*Proof, error) {
 	prf, err := ReadProof(proofFile)
 	if err != nil {
 		return nil, err
 	}
+	curve, err := CurveOf(prf)
+	if err != nil {
+		return nil, err
+	}
 
-	wit, err := ReadWitness(pubWitnessFile)
+	wit, err := ReadWitnessWithCurve(pubWitnessFile, curve)
 	if err != nil {
 		return nil, err
 	}
@@ -342,45 +471,139 @@ func Power2Index(n uint64) int {
 }
 
 func ReadSrs(size int, srsDir string) (*kzg.SRS, *kzg.SRS, error) {
-	srs := kzg.NewSRS(ecc.BN254)
-	srsLagrange := kzg.NewSRS(ecc.BN254)
-
-	sizeLagrange := ecc.NextPowerOfTwo(uint64(size))
-	index := Power2Index(sizeLagrange)
-	srsFile := filepath.Join(srsDir, fmt.Sprintf("bn254_pow_%v.srs", index))
-	lagrangeSrsFile := filepath.Join(srsDir, fmt.Sprintf("bn254_pow_%v.lsrs", index))
+	return ReadSrsWithCurve(size, srsDir, DefaultCurve)
+}
 
-	fsrs, err := os.Open(srsFile)
+// ReadSrsWithCurve reads <curve>_pow_<index>.srs and .lsrs from srsDir, e.g. bn254_pow_20.srs or bw6_761_pow_20.lsrs.
+// Without these files, the smallest larger .srs of srsDir is trimmed to index and the .lsrs is derived from it,
+// see InitSrsCache to keep them in memory and on disk.
+func ReadSrsWithCurve(size int, srsDir string, curve ecc.ID) (*kzg.SRS, *kzg.SRS, error) {
+	err := checkCurve(curve)
 	if err != nil {
 		return nil, nil, err
 	}
-	defer func() {
-		_ = fsrs.Close()
-	}()
-	flsrs, err := os.Open(lagrangeSrsFile)
+	index := Power2Index(ecc.NextPowerOfTwo(uint64(size)))
+	srs, srsLagrange, err := srsCache.read(srsDir, curve, index)
 	if err != nil {
 		return nil, nil, err
 	}
-	defer func() {
-		_ = flsrs.Close()
-	}()
+	return &srs, &srsLagrange, nil
+}
+
+// readSrsFiles reads the .srs and .lsrs of index, srsLagrange is nil if only the .lsrs is missing
+func readSrsFiles(srsDir string, curve ecc.ID, index int) (srs kzg.SRS, srsLagrange kzg.SRS, err error) {
+	sizeLagrange := 1 << index
+	srsFile, lagrangeSrsFile := SrsFiles(srsDir, curve, index)
 
-	_, err = srs.ReadFrom(fsrs)
+	srs, err = ReadSrsFile(srsFile, curve)
 	if err != nil {
 		return nil, nil, err
 	}
-	if len(srs.(*kzg_bn254.SRS).Pk.G1) != int(sizeLagrange+3) {
+	n, err := srsG1Len(srs)
+	if err != nil {
+		return nil, nil, err
+	}
+	if n != sizeLagrange+3 {
 		return nil, nil, fmt.Errorf("incorrect srs size")
 	}
 
-	_, err = srsLagrange.ReadFrom(flsrs)
+	srsLagrange, err = ReadSrsFile(lagrangeSrsFile, curve)
+	if errors.Is(err, fs.ErrNotExist) {
+		return srs, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	n, err = srsG1Len(srsLagrange)
 	if err != nil {
 		return nil, nil, err
 	}
-	if len(srsLagrange.(*kzg_bn254.SRS).Pk.G1) != int(sizeLagrange) {
+	if n != sizeLagrange {
 		return nil, nil, fmt.Errorf("incorrect srs lagrange size")
 	}
-	return &srs, &srsLagrange, nil
+	return srs, srsLagrange, nil
+}
+
+// WriteToFileAtomic is WriteFileAtomic for any io.WriterTo, e.g. keys, ccs, proofs and witnesses.
+func WriteToFileAtomic(src io.WriterTo, fn string) error {
+	return WriteFileAtomic(fn, func(w io.Writer) error {
+		_, err := src.WriteTo(w)
+		return err
+	})
+}
+
+// WriteFileAtomic streams write into a temporary file in the directory of fn, fsyncs it and renames it
+// over fn. The previous content of fn, if any, survives any failure of write, a crash or a full disk,
+// and the temporary file is removed on error.
+func WriteFileAtomic(fn string, write func(w io.Writer) error) error {
+	return writeFileAtomic(fn, func(f *os.File) error {
+		bw := bufio.NewWriterSize(f, 1<<20)
+		err := write(bw)
+		if err != nil {
+			return err
+		}
+		return bw.Flush()
+	})
+}
+
+func writeFileAtomic(fn string, write func(f *os.File) error) (err error) {
+	dir := filepath.Dir(fn)
+	err = os.MkdirAll(dir, os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	perm := os.FileMode(0644)
+	if fi, statErr := os.Stat(fn); statErr == nil {
+		perm = fi.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(fn)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+	defer func() {
+		if err != nil {
+			_ = tmp.Close()
+			_ = os.Remove(tmpName)
+		}
+	}()
+
+	err = write(tmp)
+	if err != nil {
+		return err
+	}
+	err = tmp.Chmod(perm)
+	if err != nil {
+		return err
+	}
+	err = tmp.Sync()
+	if err != nil {
+		return err
+	}
+	err = tmp.Close()
+	if err != nil {
+		return err
+	}
+
+	err = os.Rename(tmpName, fn)
+	if err != nil {
+		return err
+	}
+	return syncDir(dir)
+}
+
+// syncDir persists the directory entry of a freshly renamed file.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = d.Close()
+	}()
+	return d.Sync()
 }
 
 // todo rename ?
@@ -0,0 +1,282 @@
+package operations
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/plonk"
+	"github.com/consensys/gnark/std/algebra/emulated/sw_bn254"
+	"github.com/lightec-xyz/common/artifact"
+	"github.com/lightec-xyz/common/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+var errDiskFull = errors.New("simulated disk full")
+
+// halfWriterTo writes the first half of data, then fails
+type halfWriterTo struct {
+	data []byte
+}
+
+func (h *halfWriterTo) WriteTo(w io.Writer) (int64, error) {
+	n, err := w.Write(h.data[:len(h.data)/2])
+	if err != nil {
+		return int64(n), err
+	}
+	return int64(n), errDiskFull
+}
+
+// failingPk serializes the wrapped pk but stops halfway through
+type failingPk struct {
+	plonk.ProvingKey
+}
+
+func (f *failingPk) WriteTo(w io.Writer) (int64, error) {
+	var buf bytes.Buffer
+	if _, err := f.ProvingKey.WriteTo(&buf); err != nil {
+		return 0, err
+	}
+	return (&halfWriterTo{data: buf.Bytes()}).WriteTo(w)
+}
+
+func (f *failingPk) CurveID() ecc.ID {
+	curve, _ := CurveOf(f.ProvingKey)
+	return curve
+}
+
+// failingVk serializes the wrapped vk but stops halfway through
+type failingVk struct {
+	plonk.VerifyingKey
+}
+
+func (f *failingVk) WriteTo(w io.Writer) (int64, error) {
+	var buf bytes.Buffer
+	if _, err := f.VerifyingKey.WriteTo(&buf); err != nil {
+		return 0, err
+	}
+	return (&halfWriterTo{data: buf.Bytes()}).WriteTo(w)
+}
+
+func (f *failingVk) CurveID() ecc.ID {
+	curve, _ := CurveOf(f.VerifyingKey)
+	return curve
+}
+
+func assertNoTempFiles(t *testing.T, dir string) {
+	matches, err := filepath.Glob(filepath.Join(dir, ".*.tmp-*"))
+	assert.NoError(t, err)
+	assert.Empty(t, matches)
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	fn := filepath.Join(dir, "sub", "artifact")
+
+	err := WriteToFileAtomic(bytes.NewReader([]byte("first")), fn)
+	assert.NoError(t, err)
+	err = WriteToFileAtomic(bytes.NewReader([]byte("second")), fn)
+	assert.NoError(t, err)
+
+	content, err := os.ReadFile(fn)
+	assert.NoError(t, err)
+	assert.Equal(t, "second", string(content))
+	assertNoTempFiles(t, filepath.Dir(fn))
+}
+
+func TestWriteFileAtomic_FailingWriter(t *testing.T) {
+	dir := t.TempDir()
+	fn := filepath.Join(dir, "artifact")
+
+	err := os.WriteFile(fn, []byte("old artifact"), 0600)
+	assert.NoError(t, err)
+
+	err = WriteToFileAtomic(&halfWriterTo{data: bytes.Repeat([]byte{0xab}, 4<<20)}, fn)
+	assert.ErrorIs(t, err, errDiskFull)
+
+	content, err := os.ReadFile(fn)
+	assert.NoError(t, err)
+	assert.Equal(t, "old artifact", string(content))
+	assertNoTempFiles(t, dir)
+
+	// a failing first write must not leave anything behind either
+	fresh := filepath.Join(dir, "fresh")
+	err = WriteToFileAtomic(&halfWriterTo{data: []byte("new")}, fresh)
+	assert.ErrorIs(t, err, errDiskFull)
+	exists, err := FileExists(fresh)
+	assert.NoError(t, err)
+	assert.False(t, exists)
+	assertNoTempFiles(t, dir)
+}
+
+func TestWritePkVk_FailingHalfway(t *testing.T) {
+	dir := t.TempDir()
+	err := SetupCubicCircuit(dir)
+	assert.NoError(t, err)
+
+	pkFile := filepath.Join(dir, cubicPkFile)
+	vkFile := filepath.Join(dir, cubicVkFile)
+	oldPk, err := os.ReadFile(pkFile)
+	assert.NoError(t, err)
+	oldVk, err := os.ReadFile(vkFile)
+	assert.NoError(t, err)
+
+	pk, err := ReadPk(pkFile)
+	assert.NoError(t, err)
+	vk, err := ReadVk(vkFile)
+	assert.NoError(t, err)
+
+	err = WritePk(&failingPk{pk}, pkFile)
+	assert.ErrorIs(t, err, errDiskFull)
+	err = WriteVk(&failingVk{vk}, vkFile)
+	assert.ErrorIs(t, err, errDiskFull)
+
+	content, err := os.ReadFile(pkFile)
+	assert.NoError(t, err)
+	assert.Equal(t, oldPk, content)
+	content, err = os.ReadFile(vkFile)
+	assert.NoError(t, err)
+	assert.Equal(t, oldVk, content)
+	assertNoTempFiles(t, dir)
+
+	_, err = ReadPk(pkFile)
+	assert.NoError(t, err)
+	_, err = ReadVk(vkFile)
+	assert.NoError(t, err)
+}
+
+func TestArtifactContainer(t *testing.T) {
+	dir := t.TempDir()
+	err := SetupCubicCircuit(dir)
+	assert.NoError(t, err)
+
+	vkFile := filepath.Join(dir, cubicVkFile)
+	hdr, err := ReadArtifactHeader(vkFile)
+	assert.NoError(t, err)
+	assert.Equal(t, artifact.KindVk, hdr.Kind)
+	assert.Equal(t, ecc.BN254, hdr.Curve)
+
+	vk, err := ReadVk(vkFile)
+	assert.NoError(t, err)
+	fp, err := utils.UnsafeFingerPrintFromVk[sw_bn254.ScalarField](vk)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte(fp), hdr.FingerPrint)
+
+	fpFromFile, err := utils.UnsafeFingerPrintFromVkFile[sw_bn254.ScalarField](vkFile)
+	assert.NoError(t, err)
+	assert.Equal(t, fp, fpFromFile)
+
+	// a vk is not a pk
+	_, err = ReadPk(vkFile)
+	assert.ErrorIs(t, err, artifact.ErrKindMismatch)
+
+	// a truncated pk is detected as such rather than as a parse failure
+	pkFile := filepath.Join(dir, cubicPkFile)
+	content, err := os.ReadFile(pkFile)
+	assert.NoError(t, err)
+	truncated := filepath.Join(dir, "truncated.pk")
+	err = os.WriteFile(truncated, content[:len(content)-16], 0644)
+	assert.NoError(t, err)
+	_, err = ReadPk(truncated)
+	assert.Error(t, err)
+}
+
+func TestArtifactLegacy(t *testing.T) {
+	dir := t.TempDir()
+	err := SetupCubicCircuit(dir)
+	assert.NoError(t, err)
+
+	ccs, err := ReadCcs(filepath.Join(dir, cubicCcsFile))
+	assert.NoError(t, err)
+	pk, err := ReadPk(filepath.Join(dir, cubicPkFile))
+	assert.NoError(t, err)
+	vk, err := ReadVk(filepath.Join(dir, cubicVkFile))
+	assert.NoError(t, err)
+
+	writeLegacy := func(src io.WriterTo, fn string) string {
+		fn = filepath.Join(dir, "legacy", fn)
+		err := WriteToFileAtomic(src, fn)
+		assert.NoError(t, err)
+		_, err = ReadArtifactHeader(fn)
+		assert.ErrorIs(t, err, artifact.ErrLegacy)
+		return fn
+	}
+
+	_, err = ReadCcs(writeLegacy(ccs, cubicCcsFile))
+	assert.NoError(t, err)
+	_, err = ReadPk(writeLegacy(pk, cubicPkFile))
+	assert.NoError(t, err)
+	_, err = ReadVk(writeLegacy(vk, cubicVkFile))
+	assert.NoError(t, err)
+	_, err = utils.UnsafeFingerPrintFromVkFile[sw_bn254.ScalarField](filepath.Join(dir, "legacy", cubicVkFile))
+	assert.NoError(t, err)
+}
+
+func setupRawCubic(t testing.TB, dir string) *Config {
+	err := SetupCubicCircuit(dir)
+	assert.NoError(t, err)
+
+	pk, err := ReadPk(filepath.Join(dir, cubicPkFile))
+	assert.NoError(t, err)
+
+	config := NewCubicConfig(dir, "", dir)
+	config.PkFile = filepath.Join(dir, "cubic_raw.pk")
+	config.RawKeys = true
+	config.TrustedSource = true
+	assert.NoError(t, WritePkRaw(pk, config.PkFile))
+	return config
+}
+
+func TestRawPk(t *testing.T) {
+	dir := t.TempDir()
+	config := setupRawCubic(t, dir)
+
+	compressed, err := os.Stat(filepath.Join(dir, cubicPkFile))
+	assert.NoError(t, err)
+	raw, err := os.Stat(config.PkFile)
+	assert.NoError(t, err)
+	assert.Greater(t, raw.Size(), compressed.Size())
+
+	for _, trusted := range []bool{true, false} {
+		_, err = ReadPkRaw(config.PkFile, trusted)
+		assert.NoError(t, err)
+	}
+	// raw files stay readable by the checked path
+	_, err = ReadPk(config.PkFile)
+	assert.NoError(t, err)
+
+	instance := NewCubic(config)
+	err = instance.Load()
+	assert.NoError(t, err)
+	_, err = instance.ProveWithAssignment(&CubicCircuit{X: 3, Y: 38}, true)
+	assert.NoError(t, err)
+}
+
+func BenchmarkReadPk(b *testing.B) {
+	dir := b.TempDir()
+	config := setupRawCubic(b, dir)
+	pkFile := filepath.Join(dir, cubicPkFile)
+
+	b.Run("ReadPk", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_, err := ReadPk(pkFile)
+			assert.NoError(b, err)
+		}
+	})
+	b.Run("ReadPkRaw", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_, err := ReadPkRaw(config.PkFile, false)
+			assert.NoError(b, err)
+		}
+	})
+	b.Run("ReadPkRawTrusted", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_, err := ReadPkRaw(config.PkFile, true)
+			assert.NoError(b, err)
+		}
+	})
+}
@@ -0,0 +1,136 @@
+package operations
+
+import (
+	"context"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/backend/witness"
+	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	recursive_groth16 "github.com/consensys/gnark/std/recursion/groth16"
+	"github.com/lightec-xyz/common/artifact"
+)
+
+// Groth16 counterparts of the PLONK helpers. Groth16 circuits are compiled to R1CS and their setup is circuit
+// specific, so no SRS is involved. Proofs share ReadProof/WriteProof and the Solidity exports with PLONK.
+
+func NewR1CSConstraintSystem(circuit frontend.Circuit, curve ecc.ID) (constraint.ConstraintSystem, error) {
+	err := checkCurve(curve)
+	if err != nil {
+		return nil, err
+	}
+	ccs, err := frontend.Compile(curve.ScalarField(), r1cs.NewBuilder, circuit)
+	if err != nil {
+		return nil, err
+	}
+	return ccs, nil
+}
+
+func Groth16Setup(ccs constraint.ConstraintSystem) (groth16.ProvingKey, groth16.VerifyingKey, error) {
+	pk, vk, err := groth16.Setup(ccs)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pk, vk, nil
+}
+
+func Groth16Prove(ccs constraint.ConstraintSystem, pk groth16.ProvingKey, assignment frontend.Circuit, isFront bool) (groth16.Proof, witness.Witness, error) {
+	return Groth16ProveCtx(context.Background(), ccs, pk, assignment, isFront)
+}
+
+// Groth16ProveCtx behaves as PlonkProveCtx.
+func Groth16ProveCtx(ctx context.Context, ccs constraint.ConstraintSystem, pk groth16.ProvingKey, assignment frontend.Circuit, isFront bool) (groth16.Proof, witness.Witness, error) {
+	wit, err := newWitnessCtx(ctx, ccs, assignment)
+	if err != nil {
+		return nil, nil, err
+	}
+	proof, err := Groth16ProveWitnessCtx(ctx, ccs, pk, wit, isFront)
+	if err != nil {
+		return nil, nil, err
+	}
+	return proof, wit, nil
+}
+
+// Groth16ProveWitnessCtx behaves as PlonkProveWitnessCtx.
+func Groth16ProveWitnessCtx(ctx context.Context, ccs constraint.ConstraintSystem, pk groth16.ProvingKey, wit witness.Witness, isFront bool) (groth16.Proof, error) {
+	curve := pk.CurveID()
+	innerField := curve.ScalarField()
+	outerField := OuterCurve(curve).ScalarField()
+
+	var opts []backend.ProverOption
+	if !isFront {
+		opts = append(opts, recursive_groth16.GetNativeProverOptions(outerField, innerField))
+	}
+	return runWithContext(ctx, PhaseProve, func() (groth16.Proof, error) {
+		return groth16.Prove(ccs, pk, wit, opts...)
+	})
+}
+
+func Groth16Verify(vk groth16.VerifyingKey, proof groth16.Proof, wit witness.Witness, isFront bool) error {
+	curve := vk.CurveID()
+	innerField := curve.ScalarField()
+	outerField := OuterCurve(curve).ScalarField()
+	pubWit, err := wit.Public()
+	if err != nil {
+		return err
+	}
+
+	if isFront {
+		return groth16.Verify(proof, vk, pubWit)
+	}
+	return groth16.Verify(proof, vk, pubWit, recursive_groth16.GetNativeVerifierOptions(outerField, innerField))
+}
+
+func ReadGroth16Pk(fn string) (groth16.ProvingKey, error) {
+	return ReadGroth16PkCtx(context.Background(), fn, ecc.UNKNOWN, false)
+}
+
+// ReadGroth16PkCtx reads a Groth16 pk on curve (ecc.UNKNOWN to detect it), see ReadPkRaw for trusted.
+func ReadGroth16PkCtx(ctx context.Context, fn string, curve ecc.ID, trusted bool) (groth16.ProvingKey, error) {
+	curve, err := ResolveCurve(fn, curve)
+	if err != nil {
+		return nil, err
+	}
+	pk := groth16.NewProvingKey(curve)
+	read := pk.ReadFrom
+	if trusted {
+		read = pk.UnsafeReadFrom
+	}
+	_, err = ReadArtifactCtx(ctx, fn, artifact.KindGroth16Pk, curve, read)
+	if err != nil {
+		return nil, err
+	}
+	return pk, nil
+}
+
+func WriteGroth16Pk(pk groth16.ProvingKey, fn string) error {
+	return WriteArtifact(fn, artifact.KindGroth16Pk, pk, nil)
+}
+
+func WriteGroth16PkRaw(pk groth16.ProvingKey, fn string) error {
+	return WriteArtifact(fn, artifact.KindGroth16Pk, rawWriter{pk}, nil)
+}
+
+func ReadGroth16Vk(fn string) (groth16.VerifyingKey, error) {
+	return ReadGroth16VkCtx(context.Background(), fn, ecc.UNKNOWN)
+}
+
+func ReadGroth16VkCtx(ctx context.Context, fn string, curve ecc.ID) (groth16.VerifyingKey, error) {
+	curve, err := ResolveCurve(fn, curve)
+	if err != nil {
+		return nil, err
+	}
+	vk := groth16.NewVerifyingKey(curve)
+	_, err = ReadArtifactCtx(ctx, fn, artifact.KindGroth16Vk, curve, vk.ReadFrom)
+	if err != nil {
+		return nil, err
+	}
+	return vk, nil
+}
+
+func WriteGroth16Vk(vk groth16.VerifyingKey, fn string) error {
+	return WriteArtifact(fn, artifact.KindGroth16Vk, vk, nil)
+}
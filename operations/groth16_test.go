@@ -0,0 +1,98 @@
+package operations
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/backend/plonk"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackendLifecycle(t *testing.T) {
+	for _, id := range []backend.ID{backend.PLONK, backend.GROTH16} {
+		t.Run(id.String(), func(t *testing.T) {
+			dir := t.TempDir()
+			if id == backend.PLONK {
+				writeUnsafeSrs(t, dir, ecc.BN254)
+			}
+
+			config := NewCubicConfig(dir, dir, dir)
+			config.Backend = id
+			setup := NewCircuitOperations(config, "cubic")
+			circuit, _ := NewCubicCircuit()
+			err := setup.SetupAndSaveCcsPkVk(circuit)
+			assert.NoError(t, err)
+
+			instance := NewCircuitOperations(config, "cubic")
+			err = instance.LoadCcsPkVk()
+			assert.NoError(t, err)
+
+			vk := instance.VerifyingKey
+			if id == backend.GROTH16 {
+				assert.Implements(t, (*groth16.ProvingKey)(nil), instance.ProvingKey)
+				assert.Implements(t, (*groth16.VerifyingKey)(nil), vk)
+			} else {
+				assert.Implements(t, (*plonk.ProvingKey)(nil), instance.ProvingKey)
+			}
+
+			assignment, _ := NewCubicCircuitAssignment(3, 38)
+			for _, isFront := range []bool{true, false} {
+				proof, err := instance.ProveWithAssignment(assignment, isFront)
+				assert.NoError(t, err)
+
+				proofFile := filepath.Join(dir, "cubic.proof")
+				witnessFile := filepath.Join(dir, "cubic.wtns")
+				err = SaveProofAndWitness(proof, proofFile, witnessFile)
+				assert.NoError(t, err)
+				read, err := ReadProofAndWitness(proofFile, witnessFile)
+				assert.NoError(t, err)
+				err = instance.Verify(vk, read.Proof, read.Witness, isFront)
+				assert.NoError(t, err)
+
+				if isFront {
+					err = WriteProofInSolidity(proof.Proof, filepath.Join(dir, "cubic.proof.sol"))
+					assert.NoError(t, err)
+				}
+			}
+
+			err = WriteVkInSolidity(vk, filepath.Join(dir, "cubic.sol"))
+			assert.NoError(t, err)
+
+			_, err = instance.UnsafeFingerPrint()
+			if id == backend.GROTH16 {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestBackendMismatch(t *testing.T) {
+	dir := t.TempDir()
+	config := NewCubicConfig(dir, "", dir)
+	config.Backend = backend.GROTH16
+	instance := NewCircuitOperations(config, "cubic")
+	circuit, _ := NewCubicCircuit()
+	err := instance.SetupAndSaveCcsPkVk(circuit)
+	assert.NoError(t, err)
+	assignment, _ := NewCubicCircuitAssignment(3, 38)
+	proof, err := instance.ProveWithAssignment(assignment, true)
+	assert.NoError(t, err)
+
+	// groth16 keys and proofs are rejected by the plonk backend instead of panicking in gnark
+	plonkInstance := NewCircuitOperations(NewCubicConfig(dir, "", dir), "cubic")
+	err = plonkInstance.Verify(instance.VerifyingKey, proof.Proof, proof.Witness, true)
+	assert.ErrorContains(t, err, "expected a plonk")
+	plonkInstance.Ccs = instance.Ccs
+	plonkInstance.ProvingKey = instance.ProvingKey
+	_, err = plonkInstance.ProveWitness(proof.Witness, true)
+	assert.Error(t, err)
+
+	// and so is a missing vk
+	err = instance.Verify(nil, proof.Proof, proof.Witness, true)
+	assert.Error(t, err)
+}
@@ -1,125 +1,363 @@
 package operations
 
 import (
-	"container/list"
+	"context"
+	"errors"
 	"fmt"
-	"github.com/consensys/gnark/backend/plonk"
-	"github.com/consensys/gnark/constraint"
+	"io/fs"
+	"os"
 	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/logger"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/sync/singleflight"
 )
 
 var lruManager *LruManager
 
-func InitLru(capacity int) { //entrance for lru
-	lruManager = newLruManager(capacity)
+// InitLru sets the default cache, used by every CircuitOperations created without WithCache. It keeps capacity
+// artifacts of each kind, at least one.
+func InitLru(capacity int, opts ...LruOption) { //entrance for lru
+	lruManager = NewLruManager(capacity, opts...)
+}
+
+// InitLruWithBudget bounds the cache by estimated bytes instead of entry count, see LruBudget
+func InitLruWithBudget(budget LruBudget, opts ...LruOption) {
+	lruManager = NewLruManagerWithBudget(budget, opts...)
+}
+
+// CloseLru closes the default cache and stops using it
+func CloseLru() error {
+	if lruManager == nil {
+		return nil
+	}
+	err := lruManager.Close()
+	lruManager = nil
+	return err
+}
+
+// LruBudget holds the byte budget of each artifact kind, 0 means unbounded.
+// Groth16 keys share the pk and vk budgets with PLONK ones.
+type LruBudget struct {
+	PkBytes  int64
+	VkBytes  int64
+	CcsBytes int64
+}
+
+// Sizer can be implemented by cached values to report their in-memory size,
+// otherwise the size of the file they were read from is used as estimate.
+type Sizer interface {
+	Size() int64
 }
 
 type LruManager struct {
-	pkQueue  *LRUCache // path -> ProvingKey
-	vkQueue  *LRUCache // path -> VerifyingKey
-	ccsQueue *LRUCache // path -> css
+	pkQueue  *LRUCache[string, *cachedFile] // path -> ProvingKey
+	vkQueue  *LRUCache[string, *cachedFile] // path -> VerifyingKey
+	ccsQueue *LRUCache[string, *cachedFile] // path -> css
+
+	// concurrent misses on the same path wait for a single read
+	pkLoads  singleflight.Group
+	vkLoads  singleflight.Group
+	ccsLoads singleflight.Group
+
+	// optional second tier, files are read from their local copy
+	disk *DiskCache
+
+	preloadParallelism int
+
+	janitorLock sync.Mutex
+	stopJanitor func()
+
+	closed atomic.Bool
 }
 
-func newLruManager(capacity int) *LruManager {
-	return &LruManager{
-		pkQueue:  NewLRUCache(capacity),
-		vkQueue:  NewLRUCache(capacity),
-		ccsQueue: NewLRUCache(capacity),
+type LruOption func(*LruManager)
+
+// WithDiskCache reads the files through disk, see DiskCache
+func WithDiskCache(disk *DiskCache) LruOption {
+	return func(m *LruManager) {
+		m.disk = disk
 	}
 }
 
-func (m *LruManager) GetPk(path string) (plonk.ProvingKey, error) {
-	value, ok := m.pkQueue.Get(path)
-	if ok {
-		return value.(plonk.ProvingKey), nil
+// WithPreloadParallelism bounds the number of circuits loaded at once by Preload, 2 by default
+func WithPreloadParallelism(n int) LruOption {
+	return func(m *LruManager) {
+		m.preloadParallelism = n
 	}
-	pk, err := ReadPk(path)
-	if err != nil {
-		return nil, err
+}
+
+// WithExpiry expires the cached artifacts, see LRUCache.SetExpiry and LruManager.StartJanitor
+func WithExpiry(ttl, idle time.Duration) LruOption {
+	return func(m *LruManager) {
+		m.pkQueue.SetExpiry(ttl, idle)
+		m.vkQueue.SetExpiry(ttl, idle)
+		m.ccsQueue.SetExpiry(ttl, idle)
 	}
-	m.pkQueue.Put(path, pk)
-	return pk, nil
 }
 
-func (m *LruManager) GetVk(path string) (plonk.VerifyingKey, error) {
-	value, ok := m.vkQueue.Get(path)
-	if ok {
-		return value.(plonk.VerifyingKey), nil
+// WithClock replaces time.Now as the time source of the expiry
+func WithClock(now func() time.Time) LruOption {
+	return func(m *LruManager) {
+		m.pkQueue.SetClock(now)
+		m.vkQueue.SetClock(now)
+		m.ccsQueue.SetClock(now)
 	}
-	vk, err := ReadVk(path)
-	if err != nil {
-		return nil, err
+}
+
+func NewLruManager(capacity int, opts ...LruOption) *LruManager {
+	m := &LruManager{
+		pkQueue:            NewLRUCache[string, *cachedFile](capacity),
+		vkQueue:            NewLRUCache[string, *cachedFile](capacity),
+		ccsQueue:           NewLRUCache[string, *cachedFile](capacity),
+		preloadParallelism: 2,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+func NewLruManagerWithBudget(budget LruBudget, opts ...LruOption) *LruManager {
+	m := &LruManager{
+		pkQueue:            NewSizedLRUCache[string, *cachedFile](budget.PkBytes),
+		vkQueue:            NewSizedLRUCache[string, *cachedFile](budget.VkBytes),
+		ccsQueue:           NewSizedLRUCache[string, *cachedFile](budget.CcsBytes),
+		preloadParallelism: 2,
 	}
-	m.vkQueue.Put(path, vk)
-	return vk, nil
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// GetPk reads a missing pk as a plonk or groth16 pk, according to its header
+func (m *LruManager) GetPk(path string) (ProvingKey, error) {
+	return m.getPk(context.Background(), path, func(fn string) (ProvingKey, error) {
+		return proofSystemOfFile(fn).readPk(context.Background(), fn, ecc.UNKNOWN, false)
+	})
+}
+
+func (m *LruManager) getPk(ctx context.Context, path string, read func(string) (ProvingKey, error)) (ProvingKey, error) {
+	return getOrRead(ctx, m, m.pkQueue, &m.pkLoads, path, read)
+}
+
+// GetVk reads a missing vk as a plonk or groth16 vk, according to its header
+func (m *LruManager) GetVk(path string) (VerifyingKey, error) {
+	return m.getVk(context.Background(), path, func(fn string) (VerifyingKey, error) {
+		return proofSystemOfFile(fn).readVk(context.Background(), fn, ecc.UNKNOWN)
+	})
+}
+
+func (m *LruManager) getVk(ctx context.Context, path string, read func(string) (VerifyingKey, error)) (VerifyingKey, error) {
+	return getOrRead(ctx, m, m.vkQueue, &m.vkLoads, path, read)
 }
 
 func (m *LruManager) GetCcs(path string) (constraint.ConstraintSystem, error) {
-	value, ok := m.ccsQueue.Get(path)
-	if ok {
-		return value.(constraint.ConstraintSystem), nil
+	return m.getCcs(context.Background(), path, ReadCcs)
+}
+
+func (m *LruManager) getCcs(ctx context.Context, path string, read func(string) (constraint.ConstraintSystem, error)) (constraint.ConstraintSystem, error) {
+	return getOrRead(ctx, m, m.ccsQueue, &m.ccsLoads, path, read)
+}
+
+func (m *LruManager) Reset() {
+	m.pkQueue.Purge()
+	m.vkQueue.Purge()
+	m.ccsQueue.Purge()
+}
+
+// StartJanitor drops the expired artifacts every interval, until the returned function or Close is called
+func (m *LruManager) StartJanitor(interval time.Duration) (stop func()) {
+	stops := []func(){
+		m.pkQueue.StartJanitor(interval),
+		m.vkQueue.StartJanitor(interval),
+		m.ccsQueue.StartJanitor(interval),
 	}
-	ccs, err := ReadCcs(path)
-	if err != nil {
-		return nil, err
+	stop = func() {
+		for _, s := range stops {
+			s()
+		}
+	}
+
+	m.janitorLock.Lock()
+	defer m.janitorLock.Unlock()
+	if m.stopJanitor != nil {
+		m.stopJanitor()
 	}
-	m.ccsQueue.Put(path, ccs)
-	return ccs, nil
+	m.stopJanitor = stop
+	return stop
 }
 
-type LRUCache struct {
-	capacity int
-	cache    map[string]*list.Element
-	list     *list.List
-	lock     sync.Mutex
+func (m *LruManager) Close() error {
+	m.closed.Store(true)
+	m.janitorLock.Lock()
+	if m.stopJanitor != nil {
+		m.stopJanitor()
+		m.stopJanitor = nil
+	}
+	m.janitorLock.Unlock()
+	m.Reset()
+	return nil
 }
 
-type Element struct {
-	key   string
-	value interface{}
+// Preload loads the ccs, pk and vk of configs in the background, see WithPreloadParallelism.
+// The returned channel yields the first error met, or nil, once every config is loaded.
+func (m *LruManager) Preload(configs ...*Config) <-chan error {
+	done := make(chan error, 1)
+	go func() {
+		var g errgroup.Group
+		if m.preloadParallelism > 0 {
+			g.SetLimit(m.preloadParallelism)
+		}
+		for _, config := range configs {
+			g.Go(func() error {
+				return NewCircuitOperations(config, "preload", WithCache(m)).LoadCcsPkVk()
+			})
+		}
+		done <- g.Wait()
+	}()
+	return done
 }
 
-func NewLRUCache(capacity int) *LRUCache {
-	return &LRUCache{
-		capacity: capacity,
-		cache:    make(map[string]*list.Element),
-		list:     list.New(),
+// Pin keeps the ccs, pk and vk of configs from being evicted, whether they are loaded yet or not.
+// Pinned artifacts may make the cache exceed its capacity or budget.
+func (m *LruManager) Pin(configs ...*Config) {
+	for _, config := range configs {
+		m.ccsQueue.Pin(config.CcsFile)
+		m.pkQueue.Pin(config.PkFile)
+		m.vkQueue.Pin(config.VkFile)
 	}
 }
 
-func (l *LRUCache) Get(key string) (interface{}, bool) {
-	defer l.lock.Unlock()
-	l.lock.Lock()
-	if element, ok := l.cache[key]; ok {
-		l.list.MoveToFront(element)
-		return element.Value.(*Element).value, true
+func (m *LruManager) Unpin(configs ...*Config) {
+	for _, config := range configs {
+		m.ccsQueue.Unpin(config.CcsFile)
+		m.pkQueue.Unpin(config.PkFile)
+		m.vkQueue.Unpin(config.VkFile)
 	}
-	return nil, false
 }
 
-func (l *LRUCache) Put(key string, value interface{}) {
-	defer l.lock.Unlock()
-	l.lock.Lock()
-	if element, ok := l.cache[key]; ok {
-		element.Value.(*Element).value = value
-		l.list.MoveToFront(element)
-		return
+// LruStats holds a snapshot of the stats of each artifact kind
+type LruStats struct {
+	Pk  CacheStats
+	Vk  CacheStats
+	Ccs CacheStats
+}
+
+func (m *LruManager) Stats() LruStats {
+	return LruStats{
+		Pk:  m.pkQueue.Stats(),
+		Vk:  m.vkQueue.Stats(),
+		Ccs: m.ccsQueue.Stats(),
 	}
-	if len(l.cache) >= l.capacity {
-		backElement := l.list.Back()
-		if backElement != nil {
-			delete(l.cache, backElement.Value.(*Element).key)
-			l.list.Remove(backElement)
-			backElement.Value = nil // careful release pointer
+}
+
+// Invalidate drops path from the cache, the next Get reads it again
+func (m *LruManager) Invalidate(path string) {
+	m.pkQueue.Remove(path)
+	m.vkQueue.Remove(path)
+	m.ccsQueue.Remove(path)
+}
+
+// cachedFile is a value read from a file along with the file info at read time
+type cachedFile struct {
+	value interface{}
+	info  os.FileInfo
+}
+
+// fresh reports whether the file is still the one the value was read from. Artifacts are replaced
+// by rename, so a rewritten file is a different file even if its size and mtime happen to match.
+func (f *cachedFile) fresh(info os.FileInfo) bool {
+	return f.info != nil && info != nil &&
+		os.SameFile(f.info, info) && f.info.Size() == info.Size() && f.info.ModTime().Equal(info.ModTime())
+}
+
+// getOrRead returns the cached value of path, or reads it. Concurrent misses on path share a single read, run
+// with the reader, thus the context, of one of the callers: if that caller gives up, the others read again.
+// Each caller stops waiting with a *CanceledError once its own ctx is done.
+func getOrRead[T any](ctx context.Context, m *LruManager, queue *LRUCache[string, *cachedFile], loads *singleflight.Group, path string, read func(string) (T, error)) (T, error) {
+	var zero T
+	if m.closed.Load() {
+		return zero, ErrCacheClosed
+	}
+	info, _ := os.Stat(path)
+	entry, ok := queue.getIf(path, func(entry *cachedFile) bool {
+		_, ok := entry.value.(T)
+		return ok && entry.fresh(info)
+	})
+	if ok {
+		return entry.value.(T), nil
+	}
+	for {
+		loaded := loads.DoChan(path, func() (interface{}, error) {
+			// stat first, a change during the read makes the entry stale rather than wrongly fresh
+			info, _ := os.Stat(path)
+			// cached by a read which completed since the miss
+			if entry, ok := queue.Peek(path); ok && entry.fresh(info) {
+				if v, ok := entry.value.(T); ok {
+					return v, nil
+				}
+			}
+			v, err := readThrough(m, path, read)
+			if err != nil {
+				return nil, err
+			}
+			if !m.closed.Load() {
+				queue.PutWithSize(path, &cachedFile{value: v, info: info}, estimateSize(info, v))
+			}
+			return v, nil
+		})
+		var result singleflight.Result
+		select {
+		case <-ctx.Done():
+			return zero, &CanceledError{Phase: PhaseLoad, Err: ctx.Err()}
+		case result = <-loaded:
+		}
+		var canceled *CanceledError
+		if errors.As(result.Err, &canceled) && ctx.Err() == nil {
+			continue // canceled by the caller whose reader was used
 		}
+		if result.Err != nil {
+			return zero, result.Err
+		}
+		v, ok := result.Val.(T)
+		if !ok {
+			return zero, fmt.Errorf("%v is cached as %T", path, result.Val)
+		}
+		return v, nil
 	}
-	newElement := l.list.PushFront(&Element{key, value})
-	l.cache[key] = newElement
 }
 
-func (l *LRUCache) Display() {
-	for e := l.list.Front(); e != nil; e = e.Next() {
-		fmt.Printf("[%s: %s] ", e.Value.(*Element).key, e.Value.(*Element).value)
+// readThrough reads path from its local copy when m has a disk cache, falling back to path itself
+func readThrough[T any](m *LruManager, path string, read func(string) (T, error)) (T, error) {
+	if m.disk == nil {
+		return read(path)
+	}
+	local, err := m.disk.Local(path)
+	if err != nil {
+		log := logger.Logger()
+		log.Warn().Msgf("failed to copy %v to the disk cache: %v", path, err)
+		return read(path)
+	}
+	v, err := read(local)
+	if errors.Is(err, fs.ErrNotExist) {
+		// evicted from the disk cache meanwhile
+		return read(path)
+	}
+	return v, err
+}
+
+func estimateSize(info os.FileInfo, value interface{}) int64 {
+	if sizer, ok := value.(Sizer); ok {
+		return sizer.Size()
+	}
+	if info == nil {
+		return 0
 	}
-	fmt.Println()
+	return info.Size()
 }
@@ -1,13 +1,19 @@
 package operations
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"github.com/consensys/gnark/frontend"
 	"github.com/consensys/gnark/test/unsafekzg"
 	"github.com/stretchr/testify/assert"
 	"math/rand"
+	"os"
 	"path/filepath"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 var (
@@ -129,3 +135,373 @@ func Test_LRUCircuits(t *testing.T) {
 		instance.Prove(x, y)
 	}
 }
+
+type sized int64
+
+func (s sized) Size() int64 {
+	return int64(s)
+}
+
+func TestSizedLRUCache(t *testing.T) {
+	cache := NewSizedLRUCache[string, sized](100)
+	cache.PutWithSize("a", sized(40), 40)
+	cache.PutWithSize("b", sized(40), 40)
+	assert.Equal(t, int64(80), cache.Bytes())
+
+	// a becomes the most recently used, so b is evicted to make room for c
+	_, ok := cache.Get("a")
+	assert.True(t, ok)
+	cache.PutWithSize("c", sized(50), 50)
+	_, ok = cache.Get("b")
+	assert.False(t, ok)
+	assert.Equal(t, int64(90), cache.Bytes())
+
+	// larger than the whole budget, not cached and nothing evicted
+	cache.PutWithSize("d", sized(101), 101)
+	_, ok = cache.Get("d")
+	assert.False(t, ok)
+	assert.Equal(t, int64(90), cache.Bytes())
+
+	// replacing an entry accounts for its new size
+	cache.PutWithSize("a", sized(10), 10)
+	assert.Equal(t, int64(60), cache.Bytes())
+
+	assert.Equal(t, int64(7), estimateSize(nil, sized(7)))
+}
+
+func TestLRUCache(t *testing.T) {
+	cache := NewLRUCache[int, string](2)
+	var evicted []int
+	cache.OnEvict(func(key int, value string) {
+		evicted = append(evicted, key)
+		cache.Len() // callbacks run unlocked
+	})
+
+	cache.Put(1, "one")
+	cache.Put(2, "two")
+	value, ok := cache.Get(1)
+	assert.True(t, ok)
+	assert.Equal(t, "one", value)
+	_, ok = cache.Get(3)
+	assert.False(t, ok)
+	assert.Equal(t, []int{1, 2}, cache.Keys())
+
+	// Peek leaves 2 as the least recently used
+	value, ok = cache.Peek(2)
+	assert.True(t, ok)
+	assert.Equal(t, "two", value)
+	cache.Put(3, "three")
+	assert.Equal(t, []int{2}, evicted)
+	assert.Equal(t, []int{3, 1}, cache.Keys())
+
+	assert.True(t, cache.Remove(1))
+	assert.False(t, cache.Remove(1))
+	assert.Equal(t, 1, cache.Len())
+	cache.Put(4, "four")
+	cache.Purge()
+	assert.Equal(t, 0, cache.Len())
+	assert.ElementsMatch(t, []int{2, 1, 3, 4}, evicted)
+
+	assert.Equal(t, CacheStats{Hits: 1, Misses: 1, Evictions: 1}, cache.Stats())
+}
+
+func TestLRUCache_ZeroCapacity(t *testing.T) {
+	for _, cache := range []*LRUCache[int, string]{NewLRUCache[int, string](0), NewLRUCache[int, string](-1)} {
+		cache.Put(1, "one")
+		cache.Put(2, "two")
+		assert.Equal(t, []int{2}, cache.Keys())
+	}
+	unbounded := NewSizedLRUCache[int, string](0)
+	unbounded.Put(1, "one")
+	unbounded.Put(2, "two")
+	assert.Equal(t, 2, unbounded.Len())
+}
+
+func TestLruBudget(t *testing.T) {
+	tmpDir := t.TempDir()
+	err := SetupCubicCircuit(tmpDir)
+	assert.NoError(t, err)
+	pkFile := filepath.Join(tmpDir, cubicPkFile)
+	vkFile := filepath.Join(tmpDir, cubicVkFile)
+
+	manager := NewLruManagerWithBudget(LruBudget{PkBytes: 1, VkBytes: 1 << 20})
+	_, err = manager.GetPk(pkFile)
+	assert.NoError(t, err)
+	_, ok := manager.pkQueue.Peek(pkFile)
+	assert.False(t, ok, "pk exceeds its budget")
+
+	_, err = manager.GetVk(vkFile)
+	assert.NoError(t, err)
+	_, ok = manager.vkQueue.Peek(vkFile)
+	assert.True(t, ok)
+	info, err := os.Stat(vkFile)
+	assert.NoError(t, err)
+	assert.Equal(t, info.Size(), manager.vkQueue.Bytes())
+
+	_, err = manager.GetVk(vkFile)
+	assert.NoError(t, err)
+	stats := manager.Stats()
+	assert.Equal(t, CacheStats{Hits: 1, Misses: 1, Len: 1, Bytes: info.Size()}, stats.Vk)
+	assert.Equal(t, uint64(1), stats.Pk.Misses)
+}
+
+// waitMisses waits until every caller has missed, the reads they wait for are blocked until then
+func waitMisses(t *testing.T, queue *LRUCache[string, *cachedFile], misses uint64) {
+	assert.Eventually(t, func() bool {
+		return queue.Stats().Misses == misses
+	}, 10*time.Second, time.Millisecond)
+}
+
+func TestLruManager_ConcurrentMisses(t *testing.T) {
+	tmpDir := t.TempDir()
+	err := SetupCubicCircuit(tmpDir)
+	assert.NoError(t, err)
+	pkFile := filepath.Join(tmpDir, cubicPkFile)
+
+	var reads atomic.Int32
+	release := make(chan struct{})
+	read := func(fn string) (ProvingKey, error) {
+		reads.Add(1)
+		<-release // keep the load in flight while the others miss
+		return ReadPk(fn)
+	}
+
+	manager := NewLruManager(3)
+	const n = 32
+	pks := make([]ProvingKey, n)
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			pks[i], errs[i] = manager.getPk(context.Background(), pkFile, read)
+		}(i)
+	}
+	waitMisses(t, manager.pkQueue, n)
+	close(release)
+	wg.Wait()
+	assert.Equal(t, int32(1), reads.Load())
+	for i := 0; i < n; i++ {
+		assert.NoError(t, errs[i])
+		assert.True(t, pks[0] == pks[i], "all callers share the same key")
+	}
+
+	// errors are shared as well and not cached
+	reads.Store(0)
+	release = make(chan struct{})
+	failing := func(fn string) (ProvingKey, error) {
+		reads.Add(1)
+		<-release
+		return nil, errors.New("read failed")
+	}
+	missing := filepath.Join(tmpDir, "missing.pk")
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = manager.getPk(context.Background(), missing, failing)
+		}(i)
+	}
+	waitMisses(t, manager.pkQueue, 2*n)
+	close(release)
+	wg.Wait()
+	assert.Equal(t, int32(1), reads.Load())
+	for i := 0; i < n; i++ {
+		assert.EqualError(t, errs[i], "read failed")
+	}
+	_, ok := manager.pkQueue.Peek(missing)
+	assert.False(t, ok)
+}
+
+func TestLruManager_SharedReadCanceled(t *testing.T) {
+	tmpDir := t.TempDir()
+	err := SetupCubicCircuit(tmpDir)
+	assert.NoError(t, err)
+	pkFile := filepath.Join(tmpDir, cubicPkFile)
+	manager := NewLruManager(3)
+
+	// the first caller starts the read with its own ctx, then gives up
+	ctx, cancel := context.WithCancel(context.Background())
+	firstErr := make(chan error, 1)
+	go func() {
+		_, err := manager.getPk(ctx, pkFile, func(fn string) (ProvingKey, error) {
+			<-ctx.Done()
+			return nil, &CanceledError{Phase: PhaseLoad, Err: ctx.Err()}
+		})
+		firstErr <- err
+	}()
+	waitMisses(t, manager.pkQueue, 1)
+
+	var reads atomic.Int32
+	secondErr := make(chan error, 1)
+	go func() {
+		_, err := manager.getPk(context.Background(), pkFile, func(fn string) (ProvingKey, error) {
+			reads.Add(1)
+			return ReadPk(fn)
+		})
+		secondErr <- err
+	}()
+	waitMisses(t, manager.pkQueue, 2)
+	cancel()
+
+	var canceled *CanceledError
+	assert.ErrorAs(t, <-firstErr, &canceled)
+	assert.NoError(t, <-secondErr, "the second caller reads again")
+	assert.Equal(t, int32(1), reads.Load())
+	_, ok := manager.pkQueue.Peek(pkFile)
+	assert.True(t, ok)
+
+	// a waiting caller gives up on its own ctx
+	release := make(chan struct{})
+	defer close(release)
+	missing := filepath.Join(tmpDir, "missing.pk")
+	go func() {
+		_, _ = manager.getPk(context.Background(), missing, func(fn string) (ProvingKey, error) {
+			<-release
+			return nil, errors.New("read failed")
+		})
+	}()
+	waitMisses(t, manager.pkQueue, 3)
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	_, err = manager.getPk(ctx, missing, ReadPk)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestLRUCache_Pin(t *testing.T) {
+	cache := NewSizedLRUCache[string, sized](100)
+	cache.Pin("a")
+	cache.PutWithSize("a", sized(60), 60)
+	cache.PutWithSize("b", sized(30), 30)
+
+	// a is the least recently used but pinned, b goes
+	cache.PutWithSize("c", sized(40), 40)
+	assert.Equal(t, []string{"c", "a"}, cache.Keys())
+
+	// does not fit next to the pinned a
+	cache.PutWithSize("d", sized(50), 50)
+	_, ok := cache.Peek("d")
+	assert.False(t, ok)
+	assert.Equal(t, []string{"c", "a"}, cache.Keys())
+
+	// pinned keys are cached over budget
+	cache.Pin("e")
+	cache.PutWithSize("e", sized(200), 200)
+	assert.Equal(t, []string{"e", "a"}, cache.Keys())
+
+	cache.Unpin("a")
+	cache.Unpin("e")
+	cache.PutWithSize("f", sized(10), 10)
+	assert.Equal(t, []string{"f"}, cache.Keys())
+}
+
+func TestLruManager_PreloadPin(t *testing.T) {
+	var configs []*Config
+	for i := 0; i < 3; i++ {
+		dir := t.TempDir()
+		err := SetupCubicCircuit(dir)
+		assert.NoError(t, err)
+		configs = append(configs, NewCubicConfig(dir, "", dir))
+	}
+
+	manager := NewLruManager(2, WithPreloadParallelism(1))
+	manager.Pin(configs[0])
+	err := <-manager.Preload(configs...)
+	assert.NoError(t, err)
+
+	// the pinned circuit survives the later ones
+	_, ok := manager.pkQueue.Peek(configs[0].PkFile)
+	assert.True(t, ok)
+	_, ok = manager.pkQueue.Peek(configs[1].PkFile)
+	assert.False(t, ok)
+	_, ok = manager.pkQueue.Peek(configs[2].PkFile)
+	assert.True(t, ok)
+
+	manager.Unpin(configs[0])
+	err = <-manager.Preload(configs[1])
+	assert.NoError(t, err)
+	_, ok = manager.pkQueue.Peek(configs[0].PkFile)
+	assert.False(t, ok)
+
+	missing := NewCubicConfig(t.TempDir(), "", "")
+	err = <-manager.Preload(configs[0], missing)
+	assert.Error(t, err)
+}
+
+type fakeClock struct {
+	lock sync.Mutex
+	now  time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	defer c.lock.Unlock()
+	c.lock.Lock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	defer c.lock.Unlock()
+	c.lock.Lock()
+	c.now = c.now.Add(d)
+}
+
+func TestLRUCache_Expiry(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	cache := NewLRUCache[string, int](10)
+	cache.SetClock(clock.Now)
+	cache.SetExpiry(time.Hour, 10*time.Minute)
+
+	cache.Put("ttl", 1)
+	cache.PutWithTTL("idle", 2, 0, 0)
+	cache.Pin("pinned")
+	cache.Put("pinned", 3)
+
+	// used entries stay until their ttl
+	for i := 0; i < 5; i++ {
+		clock.Advance(9 * time.Minute)
+		_, ok := cache.Get("ttl")
+		assert.True(t, ok)
+	}
+	_, ok := cache.Peek("idle")
+	assert.False(t, ok)
+	assert.Equal(t, 1, cache.RemoveExpired())
+	assert.Equal(t, []string{"ttl", "pinned"}, cache.Keys())
+
+	clock.Advance(15 * time.Minute)
+	_, ok = cache.Get("ttl")
+	assert.False(t, ok)
+	_, ok = cache.Get("pinned")
+	assert.True(t, ok)
+	assert.Equal(t, uint64(2), cache.Stats().Expirations)
+}
+
+func TestLruManager_Janitor(t *testing.T) {
+	tmpDir := t.TempDir()
+	err := SetupCubicCircuit(tmpDir)
+	assert.NoError(t, err)
+	vkFile := filepath.Join(tmpDir, cubicVkFile)
+
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	manager := NewLruManager(3, WithExpiry(0, time.Hour), WithClock(clock.Now))
+	defer manager.Close()
+	_, err = manager.GetVk(vkFile)
+	assert.NoError(t, err)
+
+	stop := manager.StartJanitor(time.Millisecond)
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, 1, manager.vkQueue.Len())
+
+	clock.Advance(time.Hour)
+	assert.Eventually(t, func() bool {
+		return manager.vkQueue.Len() == 0
+	}, 5*time.Second, time.Millisecond)
+
+	// nothing is dropped once stopped
+	stop()
+	_, err = manager.GetVk(vkFile)
+	assert.NoError(t, err)
+	clock.Advance(time.Hour)
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, 1, manager.vkQueue.Len())
+}
@@ -0,0 +1,378 @@
+package operations
+
+import (
+	"container/list"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// LRUCache evicts the least recently used entries once it holds more than capacity entries
+// or more than maxBytes bytes, a zero limit is not enforced. Entries can also expire, see SetExpiry.
+// It is safe for concurrent use.
+type LRUCache[K comparable, V any] struct {
+	capacity int
+	maxBytes int64
+	bytes    int64
+	cache    map[K]*list.Element
+	list     *list.List
+	lock     sync.Mutex
+
+	ttl   time.Duration
+	idle  time.Duration
+	clock func() time.Time
+
+	pinned      map[K]bool
+	onEvict     []func(key K, value V)
+	hits        uint64
+	misses      uint64
+	evictions   uint64
+	expirations uint64
+}
+
+type Element[K comparable, V any] struct {
+	key     K
+	value   V
+	size    int64
+	expires time.Time // zero if the entry has no ttl
+	used    time.Time
+}
+
+// CacheStats is a snapshot of the counters of an LRUCache. Evictions only counts the entries
+// dropped to honour the limits and Expirations the expired ones, not the removed or purged ones.
+type CacheStats struct {
+	Hits        uint64
+	Misses      uint64
+	Evictions   uint64
+	Expirations uint64
+	Len         int
+	Bytes       int64
+}
+
+// NewLRUCache returns a cache of capacity entries. A capacity below 1 keeps a single entry, as it always did,
+// use NewSizedLRUCache(0) for a cache without limits.
+func NewLRUCache[K comparable, V any](capacity int) *LRUCache[K, V] {
+	return &LRUCache[K, V]{
+		capacity: max(capacity, 1),
+		cache:    make(map[K]*list.Element),
+		list:     list.New(),
+	}
+}
+
+// NewSizedLRUCache returns a cache of maxBytes bytes, see PutWithSize, without any limit if maxBytes is 0.
+func NewSizedLRUCache[K comparable, V any](maxBytes int64) *LRUCache[K, V] {
+	return &LRUCache[K, V]{
+		maxBytes: maxBytes,
+		cache:    make(map[K]*list.Element),
+		list:     list.New(),
+	}
+}
+
+// SetExpiry makes the entries put from now on expire ttl after being put, and every entry expire once
+// not used for idle. A zero duration disables the corresponding expiry, pinned entries never expire.
+// Expired entries are dropped when accessed or by RemoveExpired, see StartJanitor.
+func (l *LRUCache[K, V]) SetExpiry(ttl, idle time.Duration) {
+	defer l.lock.Unlock()
+	l.lock.Lock()
+	l.ttl = ttl
+	l.idle = idle
+}
+
+// SetClock replaces time.Now as the time source of the expiry
+func (l *LRUCache[K, V]) SetClock(now func() time.Time) {
+	defer l.lock.Unlock()
+	l.lock.Lock()
+	l.clock = now
+}
+
+func (l *LRUCache[K, V]) now() time.Time {
+	if l.clock == nil {
+		return time.Now()
+	}
+	return l.clock()
+}
+
+func (l *LRUCache[K, V]) expired(e *Element[K, V], now time.Time) bool {
+	if l.pinned[e.key] {
+		return false
+	}
+	if !e.expires.IsZero() && !now.Before(e.expires) {
+		return true
+	}
+	return l.idle > 0 && now.Sub(e.used) >= l.idle
+}
+
+// RemoveExpired drops the expired entries and returns how many there were
+func (l *LRUCache[K, V]) RemoveExpired() int {
+	var dropped []*Element[K, V]
+	defer func() { l.evicted(dropped) }()
+	defer l.lock.Unlock()
+	l.lock.Lock()
+	now := l.now()
+	for element := l.list.Back(); element != nil; {
+		prev := element.Prev()
+		if l.expired(element.Value.(*Element[K, V]), now) {
+			dropped = append(dropped, l.remove(element))
+			l.expirations++
+		}
+		element = prev
+	}
+	return len(dropped)
+}
+
+// StartJanitor calls RemoveExpired every interval until the returned function is called,
+// which returns once the janitor is done.
+func (l *LRUCache[K, V]) StartJanitor(interval time.Duration) (stop func()) {
+	done := make(chan struct{})
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				l.RemoveExpired()
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+		})
+		<-exited
+	}
+}
+
+// OnEvict registers f to be called for every entry leaving the cache, whether evicted, removed,
+// purged or replaced by a Put on its key. f runs once the cache is unlocked, so it may use the cache.
+func (l *LRUCache[K, V]) OnEvict(f func(key K, value V)) {
+	defer l.lock.Unlock()
+	l.lock.Lock()
+	l.onEvict = append(l.onEvict, f)
+}
+
+func (l *LRUCache[K, V]) Get(key K) (V, bool) {
+	return l.getIf(key, nil)
+}
+
+// getIf is Get treating an entry for which valid returns false as a miss, the entry is then removed
+func (l *LRUCache[K, V]) getIf(key K, valid func(V) bool) (V, bool) {
+	var (
+		zero    V
+		dropped []*Element[K, V]
+	)
+	defer func() { l.evicted(dropped) }()
+	defer l.lock.Unlock()
+	l.lock.Lock()
+	element, ok := l.cache[key]
+	if !ok {
+		l.misses++
+		return zero, false
+	}
+	e := element.Value.(*Element[K, V])
+	now := l.now()
+	if l.expired(e, now) {
+		l.misses++
+		l.expirations++
+		dropped = append(dropped, l.remove(element))
+		return zero, false
+	}
+	if valid != nil && !valid(e.value) {
+		l.misses++
+		dropped = append(dropped, l.remove(element))
+		return zero, false
+	}
+	l.hits++
+	e.used = now
+	l.list.MoveToFront(element)
+	return e.value, true
+}
+
+// Peek returns the value of key without updating its recency nor the stats
+func (l *LRUCache[K, V]) Peek(key K) (V, bool) {
+	defer l.lock.Unlock()
+	l.lock.Lock()
+	if element, ok := l.cache[key]; ok && !l.expired(element.Value.(*Element[K, V]), l.now()) {
+		return element.Value.(*Element[K, V]).value, true
+	}
+	var zero V
+	return zero, false
+}
+
+func (l *LRUCache[K, V]) Put(key K, value V) {
+	l.PutWithSize(key, value, 0)
+}
+
+// PutWithSize stores value as taking size bytes. A value which does not fit in the budget, even after
+// evicting every unpinned entry, is not cached unless its key is pinned.
+func (l *LRUCache[K, V]) PutWithSize(key K, value V, size int64) {
+	l.put(key, value, size, nil)
+}
+
+// PutWithTTL is PutWithSize with a ttl overriding the one of SetExpiry, 0 for none
+func (l *LRUCache[K, V]) PutWithTTL(key K, value V, size int64, ttl time.Duration) {
+	l.put(key, value, size, &ttl)
+}
+
+func (l *LRUCache[K, V]) put(key K, value V, size int64, ttl *time.Duration) {
+	var dropped []*Element[K, V]
+	defer func() { l.evicted(dropped) }()
+	defer l.lock.Unlock()
+	l.lock.Lock()
+	if element, ok := l.cache[key]; ok {
+		dropped = append(dropped, l.remove(element))
+	}
+	pinned := l.pinned[key]
+	if !pinned && l.maxBytes > 0 && size > l.maxBytes {
+		return
+	}
+	if !pinned && len(l.pinned) > 0 {
+		// do not evict anything when the pinned entries leave no room anyway
+		count, bytes := 0, int64(0)
+		for k := range l.pinned {
+			if element, ok := l.cache[k]; ok {
+				count++
+				bytes += element.Value.(*Element[K, V]).size
+			}
+		}
+		if (l.capacity > 0 && count >= l.capacity) || (l.maxBytes > 0 && bytes+size > l.maxBytes) {
+			return
+		}
+	}
+	full := func() bool {
+		return (l.capacity > 0 && len(l.cache) >= l.capacity) || (l.maxBytes > 0 && l.bytes+size > l.maxBytes)
+	}
+	for element := l.list.Back(); element != nil && full(); {
+		prev := element.Prev()
+		if !l.pinned[element.Value.(*Element[K, V]).key] {
+			dropped = append(dropped, l.remove(element))
+			l.evictions++
+		}
+		element = prev
+	}
+	e := &Element[K, V]{key: key, value: value, size: size, used: l.now()}
+	if ttl == nil {
+		ttl = &l.ttl
+	}
+	if *ttl > 0 {
+		e.expires = e.used.Add(*ttl)
+	}
+	newElement := l.list.PushFront(e)
+	l.cache[key] = newElement
+	l.bytes += size
+}
+
+// Pin protects key from eviction, whether it is cached yet or not. A pinned key may make
+// the cache exceed its limits, it still leaves the cache on Remove and Purge.
+func (l *LRUCache[K, V]) Pin(key K) {
+	defer l.lock.Unlock()
+	l.lock.Lock()
+	if l.pinned == nil {
+		l.pinned = make(map[K]bool)
+	}
+	l.pinned[key] = true
+}
+
+func (l *LRUCache[K, V]) Unpin(key K) {
+	defer l.lock.Unlock()
+	l.lock.Lock()
+	delete(l.pinned, key)
+}
+
+// Remove drops key and reports whether it was cached
+func (l *LRUCache[K, V]) Remove(key K) bool {
+	var dropped []*Element[K, V]
+	defer func() { l.evicted(dropped) }()
+	defer l.lock.Unlock()
+	l.lock.Lock()
+	element, ok := l.cache[key]
+	if ok {
+		dropped = append(dropped, l.remove(element))
+	}
+	return ok
+}
+
+// Purge removes every entry
+func (l *LRUCache[K, V]) Purge() {
+	var dropped []*Element[K, V]
+	defer func() { l.evicted(dropped) }()
+	defer l.lock.Unlock()
+	l.lock.Lock()
+	for element := l.list.Back(); element != nil; element = l.list.Back() {
+		dropped = append(dropped, l.remove(element))
+	}
+}
+
+func (l *LRUCache[K, V]) remove(element *list.Element) *Element[K, V] {
+	e := element.Value.(*Element[K, V])
+	delete(l.cache, e.key)
+	l.bytes -= e.size
+	l.list.Remove(element)
+	element.Value = nil // careful release pointer
+	return e
+}
+
+// evicted runs the OnEvict callbacks, it must be called without holding the lock
+func (l *LRUCache[K, V]) evicted(dropped []*Element[K, V]) {
+	if len(dropped) == 0 {
+		return
+	}
+	l.lock.Lock()
+	callbacks := l.onEvict
+	l.lock.Unlock()
+	for _, e := range dropped {
+		for _, f := range callbacks {
+			f(e.key, e.value)
+		}
+	}
+}
+
+func (l *LRUCache[K, V]) Len() int {
+	defer l.lock.Unlock()
+	l.lock.Lock()
+	return len(l.cache)
+}
+
+// Keys returns the cached keys, most recently used first
+func (l *LRUCache[K, V]) Keys() []K {
+	defer l.lock.Unlock()
+	l.lock.Lock()
+	keys := make([]K, 0, len(l.cache))
+	for e := l.list.Front(); e != nil; e = e.Next() {
+		keys = append(keys, e.Value.(*Element[K, V]).key)
+	}
+	return keys
+}
+
+// Bytes returns the total size of the cached values
+func (l *LRUCache[K, V]) Bytes() int64 {
+	defer l.lock.Unlock()
+	l.lock.Lock()
+	return l.bytes
+}
+
+func (l *LRUCache[K, V]) Stats() CacheStats {
+	defer l.lock.Unlock()
+	l.lock.Lock()
+	return CacheStats{
+		Hits:        l.hits,
+		Misses:      l.misses,
+		Evictions:   l.evictions,
+		Expirations: l.expirations,
+		Len:         len(l.cache),
+		Bytes:       l.bytes,
+	}
+}
+
+func (l *LRUCache[K, V]) Display() {
+	defer l.lock.Unlock()
+	l.lock.Lock()
+	for e := l.list.Front(); e != nil; e = e.Next() {
+		fmt.Printf("[%v: %v] ", e.Value.(*Element[K, V]).key, e.Value.(*Element[K, V]).value)
+	}
+	fmt.Println()
+}
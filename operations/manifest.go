@@ -0,0 +1,252 @@
+package operations
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/consensys/gnark"
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend"
+	"github.com/lightec-xyz/common/artifact"
+)
+
+// ManifestFile is the name of the manifest kept in each artifact directory
+const ManifestFile = "manifest.json"
+
+var (
+	ErrNotInManifest        = errors.New("circuit not in manifest")
+	ErrManifestHashMismatch = errors.New("file does not match its manifest hash")
+)
+
+// Manifest describes the circuits whose artifacts are in a directory, see SetupAndSaveCcsPkVk and
+// ReadManifest.
+type Manifest struct {
+	Circuits map[string]*CircuitManifest `json:"circuits"`
+
+	dir string
+}
+
+type CircuitManifest struct {
+	ComponentName       string           `json:"componentName"`
+	Backend             string           `json:"backend"`
+	Curve               string           `json:"curve"`
+	GnarkVersion        string           `json:"gnarkVersion"`
+	NbConstraints       int              `json:"nbConstraints"`
+	NbPublicVariables   int              `json:"nbPublicVariables"`
+	NbSecretVariables   int              `json:"nbSecretVariables"`
+	NbInternalVariables int              `json:"nbInternalVariables"`
+	SrsPower            int              `json:"srsPower,omitempty"` // plonk only
+	RawKeys             bool             `json:"rawKeys,omitempty"`
+	Ccs                 ManifestArtifact `json:"ccs"`
+	Pk                  ManifestArtifact `json:"pk"`
+	Vk                  ManifestArtifact `json:"vk"`
+	VkFingerPrint       string           `json:"vkFingerPrint,omitempty"` // hex, plonk only
+	CreatedAt           time.Time        `json:"createdAt"`
+}
+
+// ManifestArtifact is a file, relative to the manifest directory unless it lives elsewhere
+type ManifestArtifact struct {
+	File   string `json:"file"`
+	Size   int64  `json:"size"`
+	Sha256 string `json:"sha256"`
+}
+
+// manifestLock serializes the updates of manifests within the process
+var manifestLock sync.Mutex
+
+// ReadManifest reads the manifest of dir
+func ReadManifest(dir string) (*Manifest, error) {
+	data, err := os.ReadFile(filepath.Join(dir, ManifestFile))
+	if err != nil {
+		return nil, err
+	}
+	m := &Manifest{dir: dir}
+	err = json.Unmarshal(data, m)
+	if err != nil {
+		return nil, fmt.Errorf("read %v: %w", filepath.Join(dir, ManifestFile), err)
+	}
+	if m.Circuits == nil {
+		m.Circuits = make(map[string]*CircuitManifest)
+	}
+	return m, nil
+}
+
+// ConfigFromManifest returns the Config of the circuit name described in the manifest of dir,
+// after checking that its files match the manifest hashes.
+func ConfigFromManifest(dir string, name string) (*Config, error) {
+	m, err := ReadManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+	err = m.Verify(name)
+	if err != nil {
+		return nil, err
+	}
+	return m.Config(name)
+}
+
+func (m *Manifest) circuit(name string) (*CircuitManifest, error) {
+	c, ok := m.Circuits[name]
+	if !ok {
+		return nil, fmt.Errorf("%v: %w", name, ErrNotInManifest)
+	}
+	return c, nil
+}
+
+// Config returns the Config of the circuit name, without checking its files
+func (m *Manifest) Config(name string) (*Config, error) {
+	c, err := m.circuit(name)
+	if err != nil {
+		return nil, err
+	}
+	curve, err := ecc.IDFromString(c.Curve)
+	if err != nil {
+		return nil, err
+	}
+	id := backend.IDFromString(c.Backend)
+	if id == backend.UNKNOWN {
+		return nil, fmt.Errorf("%v: unknown backend %v", name, c.Backend)
+	}
+	return &Config{
+		CircuitDir: m.dir,
+		CcsFile:    m.path(c.Ccs.File),
+		PkFile:     m.path(c.Pk.File),
+		VkFile:     m.path(c.Vk.File),
+		Backend:    id,
+		Curve:      curve,
+		RawKeys:    c.RawKeys,
+	}, nil
+}
+
+// Verify checks the size and sha256 of the files of the circuit name
+func (m *Manifest) Verify(name string) error {
+	c, err := m.circuit(name)
+	if err != nil {
+		return err
+	}
+	for _, a := range []ManifestArtifact{c.Ccs, c.Pk, c.Vk} {
+		size, hash, err := hashFile(m.path(a.File))
+		if err != nil {
+			return err
+		}
+		if size != a.Size || hash != a.Sha256 {
+			return fmt.Errorf("%v: %w", m.path(a.File), ErrManifestHashMismatch)
+		}
+	}
+	return nil
+}
+
+func (m *Manifest) path(fn string) string {
+	if filepath.IsAbs(fn) {
+		return fn
+	}
+	return filepath.Join(m.dir, fn)
+}
+
+func (c *CircuitOperations) saveManifest() error {
+	err := c.writeManifest()
+	if err != nil {
+		c.Logger.Error().Msgf("failed to write %v manifest: %v", c.ComponentName, err)
+		return err
+	}
+	return nil
+}
+
+// writeManifest records c in the manifest of the directory of its vk
+func (c *CircuitOperations) writeManifest() error {
+	dir := filepath.Dir(c.Config.VkFile)
+	entry := &CircuitManifest{
+		ComponentName:       c.ComponentName,
+		Backend:             backend.GROTH16.String(),
+		Curve:               artifact.CurveName(c.Config.curve()),
+		GnarkVersion:        gnark.Version.String(),
+		NbConstraints:       c.Ccs.GetNbConstraints(),
+		NbPublicVariables:   c.Ccs.GetNbPublicVariables(),
+		NbSecretVariables:   c.Ccs.GetNbSecretVariables(),
+		NbInternalVariables: c.Ccs.GetNbInternalVariables(),
+		RawKeys:             c.Config.RawKeys,
+		CreatedAt:           time.Now().UTC(),
+	}
+	if c.Config.Backend != backend.GROTH16 {
+		entry.Backend = backend.PLONK.String() // the default, Config.Backend may be unset
+		entry.SrsPower = Power2Index(ecc.NextPowerOfTwo(uint64(entry.NbConstraints + entry.NbPublicVariables)))
+		fp, err := UnsafeFingerPrintFromVk(c.VerifyingKey)
+		if err != nil {
+			return err
+		}
+		entry.VkFingerPrint = hex.EncodeToString(fp)
+	}
+	for _, a := range []struct {
+		fn       string
+		artifact *ManifestArtifact
+	}{
+		{c.Config.CcsFile, &entry.Ccs},
+		{c.Config.PkFile, &entry.Pk},
+		{c.Config.VkFile, &entry.Vk},
+	} {
+		size, hash, err := hashFile(a.fn)
+		if err != nil {
+			return err
+		}
+		*a.artifact = ManifestArtifact{File: relativeTo(dir, a.fn), Size: size, Sha256: hash}
+	}
+
+	manifestLock.Lock()
+	defer manifestLock.Unlock()
+	m, err := ReadManifest(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		m, err = &Manifest{Circuits: make(map[string]*CircuitManifest), dir: dir}, nil
+	}
+	if err != nil {
+		return err
+	}
+	m.Circuits[c.ComponentName] = entry
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return WriteFileAtomic(filepath.Join(dir, ManifestFile), func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
+
+func relativeTo(dir, fn string) string {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return fn
+	}
+	absFn, err := filepath.Abs(fn)
+	if err != nil {
+		return fn
+	}
+	if filepath.Dir(absFn) == absDir {
+		return filepath.Base(absFn)
+	}
+	return absFn
+}
+
+func hashFile(fn string) (int64, string, error) {
+	f, err := os.Open(fn)
+	if err != nil {
+		return 0, "", err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	h := sha256.New()
+	size, err := io.Copy(h, f)
+	if err != nil {
+		return 0, "", err
+	}
+	return size, hex.EncodeToString(h.Sum(nil)), nil
+}
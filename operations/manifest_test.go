@@ -0,0 +1,67 @@
+package operations
+
+import (
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManifest(t *testing.T) {
+	dir := t.TempDir()
+	writeUnsafeSrs(t, dir, ecc.BN254)
+	circuit, _ := NewCubicCircuit()
+
+	setup := NewCircuitOperations(NewCubicConfig(dir, dir, dir), "cubic")
+	err := setup.SetupAndSaveCcsPkVk(circuit)
+	assert.NoError(t, err)
+	groth16Config := &Config{
+		CcsFile: filepath.Join(dir, "cubic.r1cs"),
+		PkFile:  filepath.Join(dir, "cubic.groth16.pk"),
+		VkFile:  filepath.Join(dir, "cubic.groth16.vk"),
+		Backend: backend.GROTH16,
+	}
+	err = NewCircuitOperations(groth16Config, "cubic_groth16").SetupAndSaveCcsPkVk(circuit)
+	assert.NoError(t, err)
+
+	m, err := ReadManifest(dir)
+	assert.NoError(t, err)
+	assert.Len(t, m.Circuits, 2)
+	entry := m.Circuits["cubic"]
+	assert.Equal(t, "plonk", entry.Backend)
+	assert.Equal(t, "bn254", entry.Curve)
+	assert.Equal(t, setup.Ccs.GetNbConstraints(), entry.NbConstraints)
+	assert.Equal(t, 1, entry.NbPublicVariables)
+	assert.Equal(t, 3, entry.SrsPower)
+	assert.Equal(t, cubicPkFile, entry.Pk.File)
+	fp, err := setup.UnsafeFingerPrint()
+	assert.NoError(t, err)
+	assert.Equal(t, hex.EncodeToString(fp), entry.VkFingerPrint)
+	assert.Equal(t, "groth16", m.Circuits["cubic_groth16"].Backend)
+	assert.Empty(t, m.Circuits["cubic_groth16"].VkFingerPrint)
+
+	for _, name := range []string{"cubic", "cubic_groth16"} {
+		config, err := ConfigFromManifest(dir, name)
+		assert.NoError(t, err)
+		instance := NewCircuitOperations(config, name)
+		err = instance.LoadCcsPkVk()
+		assert.NoError(t, err)
+		assignment, _ := NewCubicCircuitAssignment(3, 38)
+		_, err = instance.ProveWithAssignment(assignment, true)
+		assert.NoError(t, err)
+	}
+
+	_, err = ConfigFromManifest(dir, "missing")
+	assert.ErrorIs(t, err, ErrNotInManifest)
+
+	err = os.WriteFile(filepath.Join(dir, cubicVkFile), []byte("tampered"), 0644)
+	assert.NoError(t, err)
+	_, err = ConfigFromManifest(dir, "cubic")
+	assert.ErrorIs(t, err, ErrManifestHashMismatch)
+	_, err = ConfigFromManifest(dir, "cubic_groth16")
+	assert.NoError(t, err)
+}
@@ -1,54 +1,64 @@
 package operations
 
 import (
+	"context"
+
 	"github.com/consensys/gnark-crypto/ecc"
 	"github.com/consensys/gnark-crypto/kzg"
+	"github.com/consensys/gnark/backend"
 	native_plonk "github.com/consensys/gnark/backend/plonk"
 	"github.com/consensys/gnark/backend/witness"
 	"github.com/consensys/gnark/constraint"
 	"github.com/consensys/gnark/frontend"
 	"github.com/consensys/gnark/frontend/cs/scs"
 	"github.com/consensys/gnark/logger"
-	"github.com/consensys/gnark/std/algebra/emulated/sw_bn254"
 	"github.com/consensys/gnark/std/recursion/plonk"
-	"github.com/lightec-xyz/common/utils"
 	"github.com/rs/zerolog"
 )
 
 type CircuitOperations struct {
-	ProvingKey    native_plonk.ProvingKey
-	VerifyingKey  native_plonk.VerifyingKey
-	Ccs           constraint.ConstraintSystem
+	// ProvingKey and VerifyingKey are plonk or groth16 keys, depending on Config.Backend
+	ProvingKey   ProvingKey
+	VerifyingKey VerifyingKey
+	Ccs          constraint.ConstraintSystem
+
 	Config        *Config
 	ComponentName string
 	Logger        *zerolog.Logger
+
+	cache    Cache
+	cacheSet bool
 }
 
-func NewCircuitOperations(config *Config, componentName string) *CircuitOperations {
+func NewCircuitOperations(config *Config, componentName string, opts ...Option) *CircuitOperations {
 	log := logger.Logger().With().Str("component", componentName).Logger()
-	return &CircuitOperations{
+	c := &CircuitOperations{
 		Config:        config,
 		ComponentName: componentName,
 		Logger:        &log,
 	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
 }
 
 func (c *CircuitOperations) SetupAndSaveCcsPkVk(circuit frontend.Circuit) error {
 	log := logger.Logger().With().Str("component", c.ComponentName).Logger()
 
-	ccs, err := NewConstraintSystem(circuit)
+	s, err := c.Config.proofSystem()
 	if err != nil {
-		log.Error().Msgf("failed to new %v constraint system: %v", c.ComponentName, err)
+		log.Error().Msgf("failed to setup %v: %v", c.ComponentName, err)
 		return err
 	}
 
-	srs, lsrs, err := ReadSrs(ccs.GetNbConstraints()+ccs.GetNbPublicVariables(), c.Config.SrsDir)
+	ccs, err := s.compile(circuit, c.Config.curve())
 	if err != nil {
-		log.Error().Msgf("failed to read srs: %v", err)
+		log.Error().Msgf("failed to new %v constraint system: %v", c.ComponentName, err)
 		return err
 	}
 
-	pk, vk, err := PlonkSetup(ccs, srs, lsrs)
+	pk, vk, err := s.setup(ccs, c.Config.SrsDir)
 	if err != nil {
 		log.Error().Msgf("failed to init %v pk vk: %v", c.ComponentName, err)
 		return err
@@ -59,26 +69,32 @@ func (c *CircuitOperations) SetupAndSaveCcsPkVk(circuit frontend.Circuit) error
 	c.VerifyingKey = vk
 	c.Logger = &log
 
-	return c.saveCcsPkVk()
+	err = c.saveCcsPkVk()
+	if err != nil {
+		return err
+	}
+	return c.saveManifest()
 }
 
 func (c *CircuitOperations) LoadCcsPkVk() error {
+	return c.LoadCcsPkVkCtx(context.Background())
+}
+
+// LoadCcsPkVkCtx is LoadCcsPkVk giving up with a *CanceledError once ctx is done.
+func (c *CircuitOperations) LoadCcsPkVkCtx(ctx context.Context) error {
 	log := logger.Logger().With().Str("component", c.ComponentName).Logger()
 	c.Logger = &log
-	if lruManager != nil {
-		return c.loadCcsPkVkWithLRU()
-	}
-	ccs, err := ReadCcs(c.Config.CcsFile)
+	ccs, err := c.loadCcs(ctx)
 	if err != nil {
 		c.Logger.Error().Msgf("failed to read %v ccs: %v", c.ComponentName, err)
 		return err
 	}
-	pk, err := ReadPk(c.Config.PkFile)
+	pk, err := c.loadPk(ctx)
 	if err != nil {
 		c.Logger.Error().Msgf("failed to read %v pk: %v", c.ComponentName, err)
 		return err
 	}
-	vk, err := ReadVk(c.Config.VkFile)
+	vk, err := c.loadVk(ctx)
 	if err != nil {
 		c.Logger.Error().Msgf("failed to read %v vk: %v", c.ComponentName, err)
 		return err
@@ -87,42 +103,62 @@ func (c *CircuitOperations) LoadCcsPkVk() error {
 	c.ProvingKey = pk
 	c.VerifyingKey = vk
 
+	if c.Config.ValidateOnLoad {
+		err = c.ValidateArtifacts()
+		if err != nil {
+			c.Logger.Error().Msgf("failed to validate %v artifacts: %v", c.ComponentName, err)
+			return err
+		}
+	}
 	return nil
 }
 
-func (c *CircuitOperations) loadCcsPkVkWithLRU() error {
-	css, err := lruManager.GetCcs(c.Config.CcsFile)
-	if err != nil {
-		c.Logger.Error().Msgf("failed to get %v ccs: %v", c.ComponentName, err)
-		return err
-	}
-	c.Ccs = css
-	pk, err := lruManager.GetPk(c.Config.PkFile)
-	if err != nil {
-		c.Logger.Error().Msgf("failed get read %v pk: %v", c.ComponentName, err)
-		return err
-	}
-	c.ProvingKey = pk
-	vk, err := lruManager.GetVk(c.Config.VkFile)
+func (c *CircuitOperations) ProveWithAssignment(assignment frontend.Circuit, isFront bool) (*Proof, error) {
+	return c.ProveWithAssignmentCtx(context.Background(), assignment, isFront)
+}
+
+// ProveWithAssignmentCtx is ProveWithAssignment returning a *CanceledError as soon as ctx is done,
+// at the latest between the witness, prove and verify phases.
+func (c *CircuitOperations) ProveWithAssignmentCtx(ctx context.Context, assignment frontend.Circuit, isFront bool) (*Proof, error) {
+	wit, err := newWitnessCtx(ctx, c.Ccs, assignment)
 	if err != nil {
-		c.Logger.Error().Msgf("failed get read %v vk: %v", c.ComponentName, err)
-		return err
+		c.Logger.Error().Msgf("failed to prove %v: %v", c.ComponentName, err)
+		return nil, err
 	}
-	c.VerifyingKey = vk
-	return nil
+	return c.ProveWitnessCtx(ctx, wit, isFront)
 }
 
-func (c *CircuitOperations) ProveWithAssignment(assignment frontend.Circuit, isFront bool) (*Proof, error) {
-	proof, wit, err := PlonkProve(c.Ccs, c.ProvingKey, assignment, isFront)
+// ProveWitness proves a full witness, typically returned by Solve, with the loaded ccs and pk. The proof is
+// verified unless Config.SkipVerify.
+func (c *CircuitOperations) ProveWitness(wit witness.Witness, isFront bool) (*Proof, error) {
+	return c.ProveWitnessCtx(context.Background(), wit, isFront)
+}
+
+// ProveWitnessCtx is ProveWitness returning a *CanceledError as soon as ctx is done.
+func (c *CircuitOperations) ProveWitnessCtx(ctx context.Context, wit witness.Witness, isFront bool) (*Proof, error) {
+	s, err := c.Config.proofSystem()
 	if err != nil {
 		c.Logger.Error().Msgf("failed to prove %v: %v", c.ComponentName, err)
 		return nil, err
 	}
-	err = PlonkVerify(c.VerifyingKey, proof, wit, isFront)
+	proof, err := s.prove(ctx, c.Ccs, c.ProvingKey, wit, isFront)
 	if err != nil {
-		c.Logger.Error().Msgf("failed to verify %v: %v", c.ComponentName, err)
+		c.Logger.Error().Msgf("failed to prove %v: %v", c.ComponentName, err)
 		return nil, err
 	}
+	if !c.Config.SkipVerify {
+		err = ctx.Err()
+		if err != nil {
+			err = &CanceledError{Phase: PhaseVerify, Err: err}
+			c.Logger.Error().Msgf("failed to verify %v: %v", c.ComponentName, err)
+			return nil, err
+		}
+		err = s.verify(c.VerifyingKey, proof, wit, isFront)
+		if err != nil {
+			c.Logger.Error().Msgf("failed to verify %v: %v", c.ComponentName, err)
+			return nil, err
+		}
+	}
 	return &Proof{
 		Proof:   proof,
 		Witness: wit,
@@ -130,17 +166,19 @@ func (c *CircuitOperations) ProveWithAssignment(assignment frontend.Circuit, isF
 }
 
 func (c *CircuitOperations) saveCcsPkVk() error {
+	defer c.invalidateCache()
+
 	err := WriteCcs(c.Ccs, c.Config.CcsFile)
 	if err != nil {
 		c.Logger.Error().Msgf("failed to write %v ccs: %v", c.ComponentName, err)
 		return err
 	}
-	err = WritePk(c.ProvingKey, c.Config.PkFile)
+	err = c.Config.writePk(c.ProvingKey, c.Config.PkFile)
 	if err != nil {
 		c.Logger.Error().Msgf("failed to write %v pk: %v", c.ComponentName, err)
 		return err
 	}
-	err = WriteVk(c.VerifyingKey, c.Config.VkFile)
+	err = c.Config.writeVk(c.VerifyingKey, c.Config.VkFile)
 	if err != nil {
 		c.Logger.Error().Msgf("failed to write %v vk: %v", c.ComponentName, err)
 		return err
@@ -148,9 +186,9 @@ func (c *CircuitOperations) saveCcsPkVk() error {
 	return nil
 }
 
-func (c *CircuitOperations) GetVerifyingKey() (native_plonk.VerifyingKey, error) {
+func (c *CircuitOperations) GetVerifyingKey() (VerifyingKey, error) {
 	if c.VerifyingKey == nil {
-		verifyingKey, err := ReadVk(c.Config.VkFile)
+		verifyingKey, err := c.Config.readVk(context.Background(), c.Config.VkFile)
 		if err != nil {
 			c.Logger.Error().Msgf("failed to get %v vk: %v", c.ComponentName, err)
 			return nil, err
@@ -160,13 +198,14 @@ func (c *CircuitOperations) GetVerifyingKey() (native_plonk.VerifyingKey, error)
 	return c.VerifyingKey, nil
 }
 
+// UnsafeFingerPrint is only defined for PLONK, a Groth16 vk is an error
 func (c *CircuitOperations) UnsafeFingerPrint() ([]byte, error) {
 	verifyingKey, err := c.GetVerifyingKey()
 	if err != nil {
 		c.Logger.Error().Msgf("failed to get %v vk: %v", c.ComponentName, err)
 		return nil, err
 	}
-	vkFigurePrint, err := utils.UnsafeFingerPrintFromVk[sw_bn254.ScalarField](verifyingKey)
+	vkFigurePrint, err := UnsafeFingerPrintFromVk(verifyingKey)
 	if err != nil {
 		c.Logger.Error().Msgf("failed to get %v fingerprint: %v", c.ComponentName, err)
 		return nil, err
@@ -176,7 +215,7 @@ func (c *CircuitOperations) UnsafeFingerPrint() ([]byte, error) {
 
 func (c *CircuitOperations) ConstraintSystem() (constraint.ConstraintSystem, error) {
 	if c.Ccs == nil {
-		ccs, err := ReadCcs(c.Config.CcsFile)
+		ccs, err := c.Config.readCcs(context.Background(), c.Config.CcsFile)
 		if err != nil {
 			c.Logger.Error().Msgf("failed to read %v ccs: %v", c.ComponentName, err)
 			return nil, err
@@ -186,12 +225,25 @@ func (c *CircuitOperations) ConstraintSystem() (constraint.ConstraintSystem, err
 	return c.Ccs, nil
 }
 
-func (c *CircuitOperations) Verify(vk native_plonk.VerifyingKey, proof native_plonk.Proof, wit witness.Witness, isFront bool) error {
-	return PlonkVerify(vk, proof, wit, isFront)
+// Verify verifies a proof of the backend of c.Config with vk, e.g. c.VerifyingKey.
+func (c *CircuitOperations) Verify(vk VerifyingKey, proof SnarkProof, wit witness.Witness, isFront bool) error {
+	s, err := c.Config.proofSystem()
+	if err != nil {
+		return err
+	}
+	return s.verify(vk, proof, wit, isFront)
 }
 
 func NewConstraintSystem(circuit frontend.Circuit) (constraint.ConstraintSystem, error) {
-	field := ecc.BN254.ScalarField()
+	return NewConstraintSystemWithCurve(circuit, DefaultCurve)
+}
+
+func NewConstraintSystemWithCurve(circuit frontend.Circuit, curve ecc.ID) (constraint.ConstraintSystem, error) {
+	err := checkCurve(curve)
+	if err != nil {
+		return nil, err
+	}
+	field := curve.ScalarField()
 	ccs, err := frontend.Compile(field, scs.NewBuilder, circuit)
 	if err != nil {
 		return nil, err
@@ -207,32 +259,50 @@ func PlonkSetup(ccs constraint.ConstraintSystem, srs *kzg.SRS, srsLagrange *kzg.
 	return pk, vk, err
 }
 
+// PlonkProve proves on the curve of ccs. Unless isFront, the proof is made for recursive verification on OuterCurve.
 func PlonkProve(ccs constraint.ConstraintSystem, pk native_plonk.ProvingKey, assignment frontend.Circuit, isFront bool) (native_plonk.Proof, witness.Witness, error) {
-	innerField := ecc.BN254.ScalarField()
-	outerField := ecc.BN254.ScalarField()
-	wit, err := frontend.NewWitness(assignment, innerField)
+	return PlonkProveCtx(context.Background(), ccs, pk, assignment, isFront)
+}
+
+// PlonkProveCtx is PlonkProve returning a *CanceledError once ctx is done. The prover itself cannot be
+// interrupted: it keeps running in the background and its result is abandoned.
+func PlonkProveCtx(ctx context.Context, ccs constraint.ConstraintSystem, pk native_plonk.ProvingKey, assignment frontend.Circuit, isFront bool) (native_plonk.Proof, witness.Witness, error) {
+	wit, err := newWitnessCtx(ctx, ccs, assignment)
 	if err != nil {
 		return nil, nil, err
 	}
-	var proof native_plonk.Proof
-	if isFront {
-		proof, err = native_plonk.Prove(ccs, pk, wit)
-		if err != nil {
-			return nil, nil, err
-		}
-	} else {
-		proof, err = native_plonk.Prove(ccs, pk, wit, plonk.GetNativeProverOptions(outerField, innerField))
-		if err != nil {
-			return nil, nil, err
-		}
+	proof, err := PlonkProveWitnessCtx(ctx, ccs, pk, wit, isFront)
+	if err != nil {
+		return nil, nil, err
 	}
-
 	return proof, wit, nil
 }
 
+// PlonkProveWitnessCtx is PlonkProveCtx for a full witness already built, e.g. by Solve.
+func PlonkProveWitnessCtx(ctx context.Context, ccs constraint.ConstraintSystem, pk native_plonk.ProvingKey, wit witness.Witness, isFront bool) (native_plonk.Proof, error) {
+	curve, err := CurveOf(ccs)
+	if err != nil {
+		return nil, err
+	}
+	innerField := curve.ScalarField()
+	outerField := OuterCurve(curve).ScalarField()
+
+	var opts []backend.ProverOption
+	if !isFront {
+		opts = append(opts, plonk.GetNativeProverOptions(outerField, innerField))
+	}
+	return runWithContext(ctx, PhaseProve, func() (native_plonk.Proof, error) {
+		return native_plonk.Prove(ccs, pk, wit, opts...)
+	})
+}
+
 func PlonkVerify(vk native_plonk.VerifyingKey, proof native_plonk.Proof, wit witness.Witness, isFront bool) error {
-	innerField := ecc.BN254.ScalarField()
-	outerField := ecc.BN254.ScalarField()
+	curve, err := CurveOf(vk)
+	if err != nil {
+		return err
+	}
+	innerField := curve.ScalarField()
+	outerField := OuterCurve(curve).ScalarField()
 	pubWit, err := wit.Public()
 	if err != nil {
 		return err
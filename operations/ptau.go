@@ -0,0 +1,298 @@
+package operations
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fp"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	kzg_bn254 "github.com/consensys/gnark-crypto/ecc/bn254/kzg"
+)
+
+// snarkjs .ptau layout: "ptau", version and number of sections as uint32, then sections made of a uint32 type,
+// a uint64 size and the data. Field elements are 32 bytes little endian in Montgomery form, as fp.Element.
+const (
+	ptauMagic         = "ptau"
+	ptauSectionHeader = 1 // n8, q, power, ceremony power
+	ptauSectionTauG1  = 2 // [τⁱ]G₁ for i < 2^(power+1)-1
+	ptauSectionTauG2  = 3 // [τⁱ]G₂ for i < 2^power
+
+	ptauFrBytes = 32
+	ptauG1Bytes = 2 * ptauFrBytes
+	ptauG2Bytes = 4 * ptauFrBytes
+)
+
+var ErrInvalidTranscript = errors.New("invalid powers of tau transcript")
+
+// SrsSource records where an imported SRS comes from, it is written next to the SRS files, see SrsSourceFile
+type SrsSource struct {
+	Format        string    `json:"format"`
+	File          string    `json:"file"`
+	Size          int64     `json:"size"`
+	Sha256        string    `json:"sha256"`
+	Power         int       `json:"power"`         // of the transcript
+	CeremonyPower int       `json:"ceremonyPower"` // of the ceremony the transcript was cut from
+	ImportedPower int       `json:"importedPower"`
+	ImportedAt    time.Time `json:"importedAt"`
+}
+
+// SrsSourceFile returns the file recording the source of the SRS files of power in srsDir
+func SrsSourceFile(srsDir string, curve ecc.ID, power int) string {
+	return filepath.Join(srsDir, fmt.Sprintf("%v_pow_%v.source.json", curve, power))
+}
+
+// ReadSrsSource reads the source recorded by ImportPtau for the SRS files of power in srsDir
+func ReadSrsSource(srsDir string, curve ecc.ID, power int) (*SrsSource, error) {
+	data, err := os.ReadFile(SrsSourceFile(srsDir, curve, power))
+	if err != nil {
+		return nil, err
+	}
+	source := &SrsSource{}
+	err = json.Unmarshal(data, source)
+	if err != nil {
+		return nil, err
+	}
+	return source, nil
+}
+
+// ImportPtau converts the BN254 snarkjs powers of tau transcript ptauFile into the canonical and Lagrange SRS
+// of power in srsDir, along with its SrsSource. The points are checked to be on the curve (and in G₂ for the G₂
+// ones), to start from the generators and to be successive powers of the τ of [τ]G₂, by a pairing check on a
+// random linear combination. Only the snarkjs format is supported, not e.g. the Aztec Ignition one.
+func ImportPtau(ptauFile string, srsDir string, power int) (*SrsSource, error) {
+	f, err := os.Open(ptauFile)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	sections, err := readPtauSections(f)
+	if err != nil {
+		return nil, err
+	}
+
+	source := &SrsSource{Format: "ptau", File: filepath.Base(ptauFile), ImportedPower: power}
+	source.Power, source.CeremonyPower, err = readPtauHeader(f, sections[ptauSectionHeader])
+	if err != nil {
+		return nil, err
+	}
+	if power > source.Power {
+		return nil, fmt.Errorf("%w: power %v, %v requested", ErrInvalidTranscript, source.Power, power)
+	}
+
+	n := 1<<power + 3
+	g1 := make([]bn254.G1Affine, n)
+	err = readPtauPoints(f, sections[ptauSectionTauG1], 2<<source.Power-1, ptauG1Bytes, len(g1), func(i int, buf []byte) error {
+		return readPtauG1(&g1[i], buf)
+	})
+	if err != nil {
+		return nil, err
+	}
+	var g2 [2]bn254.G2Affine
+	err = readPtauPoints(f, sections[ptauSectionTauG2], 1<<source.Power, ptauG2Bytes, len(g2), func(i int, buf []byte) error {
+		return readPtauG2(&g2[i], buf)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	srs := &kzg_bn254.SRS{Pk: kzg_bn254.ProvingKey{G1: g1}}
+	srs.Vk.G1 = g1[0]
+	srs.Vk.G2 = g2
+	err = verifyPowersOfTau(srs)
+	if err != nil {
+		return nil, err
+	}
+	srs.Vk.Lines[0] = bn254.PrecomputeLines(srs.Vk.G2[0])
+	srs.Vk.Lines[1] = bn254.PrecomputeLines(srs.Vk.G2[1])
+
+	lsrs, err := LagrangeSrs(srs, power)
+	if err != nil {
+		return nil, err
+	}
+	source.Size, source.Sha256, err = hashFile(ptauFile)
+	if err != nil {
+		return nil, err
+	}
+	source.ImportedAt = time.Now().UTC()
+
+	err = WriteSrs(srsDir, power, srs, lsrs)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.MarshalIndent(source, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	err = WriteFileAtomic(SrsSourceFile(srsDir, ecc.BN254, power), func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return source, nil
+}
+
+type ptauSection struct {
+	offset int64
+	size   uint64
+}
+
+func readPtauSections(f *os.File) (map[uint32]ptauSection, error) {
+	var header [12]byte
+	_, err := io.ReadFull(f, header[:])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTranscript, err)
+	}
+	if string(header[:4]) != ptauMagic {
+		return nil, fmt.Errorf("%w: not a ptau file", ErrInvalidTranscript)
+	}
+	nbSections := binary.LittleEndian.Uint32(header[8:])
+	sections := make(map[uint32]ptauSection, nbSections)
+	offset := int64(len(header))
+	for i := uint32(0); i < nbSections; i++ {
+		var sectionHeader [12]byte
+		_, err := f.ReadAt(sectionHeader[:], offset)
+		if err != nil {
+			return nil, fmt.Errorf("%w: section %v: %v", ErrInvalidTranscript, i, err)
+		}
+		typ := binary.LittleEndian.Uint32(sectionHeader[:4])
+		size := binary.LittleEndian.Uint64(sectionHeader[4:])
+		if _, ok := sections[typ]; ok {
+			return nil, fmt.Errorf("%w: duplicate section %v", ErrInvalidTranscript, typ)
+		}
+		sections[typ] = ptauSection{offset: offset + int64(len(sectionHeader)), size: size}
+		offset += int64(len(sectionHeader)) + int64(size)
+	}
+	for _, typ := range []uint32{ptauSectionHeader, ptauSectionTauG1, ptauSectionTauG2} {
+		if _, ok := sections[typ]; !ok {
+			return nil, fmt.Errorf("%w: missing section %v", ErrInvalidTranscript, typ)
+		}
+	}
+	return sections, nil
+}
+
+func readPtauHeader(f *os.File, section ptauSection) (power int, ceremonyPower int, err error) {
+	if section.size != 4+ptauFrBytes+8 {
+		return 0, 0, fmt.Errorf("%w: header of %v bytes, only BN254 is supported", ErrInvalidTranscript, section.size)
+	}
+	buf := make([]byte, section.size)
+	_, err = f.ReadAt(buf, section.offset)
+	if err != nil {
+		return 0, 0, fmt.Errorf("%w: %v", ErrInvalidTranscript, err)
+	}
+	if binary.LittleEndian.Uint32(buf) != ptauFrBytes {
+		return 0, 0, fmt.Errorf("%w: only BN254 is supported", ErrInvalidTranscript)
+	}
+	q := make([]byte, ptauFrBytes)
+	for i := range q {
+		q[i] = buf[4+ptauFrBytes-1-i]
+	}
+	if new(big.Int).SetBytes(q).Cmp(fp.Modulus()) != 0 {
+		return 0, 0, fmt.Errorf("%w: only BN254 is supported", ErrInvalidTranscript)
+	}
+	power = int(binary.LittleEndian.Uint32(buf[4+ptauFrBytes:]))
+	ceremonyPower = int(binary.LittleEndian.Uint32(buf[8+ptauFrBytes:]))
+	if power > 30 {
+		return 0, 0, fmt.Errorf("%w: power %v", ErrInvalidTranscript, power)
+	}
+	return power, ceremonyPower, nil
+}
+
+// readPtauPoints reads the first n points of pointSize bytes of a section holding nbPoints, calling read for each one
+func readPtauPoints(f *os.File, section ptauSection, nbPoints uint64, pointSize int, n int, read func(i int, buf []byte) error) error {
+	if section.size != nbPoints*uint64(pointSize) {
+		return fmt.Errorf("%w: section of %v bytes, %v expected", ErrInvalidTranscript, section.size, nbPoints*uint64(pointSize))
+	}
+	r := bufio.NewReaderSize(io.NewSectionReader(f, section.offset, int64(section.size)), 1<<20)
+	buf := make([]byte, pointSize)
+	for i := 0; i < n; i++ {
+		_, err := io.ReadFull(r, buf)
+		if err != nil {
+			return fmt.Errorf("%w: point %v: %v", ErrInvalidTranscript, i, err)
+		}
+		err = read(i, buf)
+		if err != nil {
+			return fmt.Errorf("%w: point %v: %v", ErrInvalidTranscript, i, err)
+		}
+	}
+	return nil
+}
+
+func readPtauElement(e *fp.Element, buf []byte) {
+	for i := range e {
+		e[i] = binary.LittleEndian.Uint64(buf[8*i:])
+	}
+}
+
+func readPtauG1(p *bn254.G1Affine, buf []byte) error {
+	readPtauElement(&p.X, buf)
+	readPtauElement(&p.Y, buf[ptauFrBytes:])
+	// G₁ has no cofactor
+	if !p.IsOnCurve() {
+		return errors.New("G1 point not on curve")
+	}
+	return nil
+}
+
+func readPtauG2(p *bn254.G2Affine, buf []byte) error {
+	readPtauElement(&p.X.A0, buf)
+	readPtauElement(&p.X.A1, buf[ptauFrBytes:])
+	readPtauElement(&p.Y.A0, buf[2*ptauFrBytes:])
+	readPtauElement(&p.Y.A1, buf[3*ptauFrBytes:])
+	if !p.IsInSubGroup() {
+		return errors.New("G2 point not in subgroup")
+	}
+	return nil
+}
+
+// verifyPowersOfTau checks that srs starts from the generators and that e(G1[i+1], G₂) = e(G1[i], [τ]G₂)
+// for every i, as a single pairing check on a random linear combination of the G1 points.
+func verifyPowersOfTau(srs *kzg_bn254.SRS) error {
+	_, _, g1, g2 := bn254.Generators()
+	if !srs.Pk.G1[0].Equal(&g1) || !srs.Vk.G2[0].Equal(&g2) {
+		return fmt.Errorf("%w: powers do not start from the generators", ErrInvalidTranscript)
+	}
+	if srs.Vk.G2[1].IsInfinity() || srs.Vk.G2[1].Equal(&g2) {
+		return fmt.Errorf("%w: degenerate τ", ErrInvalidTranscript)
+	}
+
+	n := len(srs.Pk.G1) - 1
+	r := make([]fr.Element, n)
+	for i := range r {
+		_, err := r[i].SetRandom()
+		if err != nil {
+			return err
+		}
+	}
+	var lower, upper bn254.G1Affine
+	_, err := lower.MultiExp(srs.Pk.G1[:n], r, ecc.MultiExpConfig{})
+	if err != nil {
+		return err
+	}
+	_, err = upper.MultiExp(srs.Pk.G1[1:], r, ecc.MultiExpConfig{})
+	if err != nil {
+		return err
+	}
+	lower.Neg(&lower)
+	ok, err := bn254.PairingCheck([]bn254.G1Affine{upper, lower}, []bn254.G2Affine{g2, srs.Vk.G2[1]})
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("%w: G1 points are not successive powers of the τ of G2", ErrInvalidTranscript)
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package operations
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fp"
+	kzg_bn254 "github.com/consensys/gnark-crypto/ecc/bn254/kzg"
+	"github.com/stretchr/testify/assert"
+)
+
+func appendPtauElement(buf []byte, e *fp.Element) []byte {
+	for _, limb := range e {
+		buf = binary.LittleEndian.AppendUint64(buf, limb)
+	}
+	return buf
+}
+
+func appendPtauSection(buf []byte, typ uint32, data []byte) []byte {
+	buf = binary.LittleEndian.AppendUint32(buf, typ)
+	buf = binary.LittleEndian.AppendUint64(buf, uint64(len(data)))
+	return append(buf, data...)
+}
+
+// writePtau writes a ptau file of power with the toxic value of unsafekzg.WithToxicSeed(seed), tamper may change
+// its points before they are encoded
+func writePtau(t *testing.T, fn string, power int, seed []byte, tamper func(g1 []bn254.G1Affine, g2 []bn254.G2Affine)) {
+	h := sha256.Sum256(seed)
+	tau := new(big.Int).SetBytes(h[:])
+	srs, err := kzg_bn254.NewSRS(uint64(2<<power-1), tau)
+	assert.NoError(t, err)
+	g1 := srs.Pk.G1
+	g2 := make([]bn254.G2Affine, 1<<power)
+	g2[0] = srs.Vk.G2[0]
+	for i := 1; i < len(g2); i++ {
+		g2[i].ScalarMultiplication(&g2[i-1], tau)
+	}
+	if tamper != nil {
+		tamper(g1, g2)
+	}
+
+	var header []byte
+	header = binary.LittleEndian.AppendUint32(header, 32)
+	q := fp.Modulus().FillBytes(make([]byte, 32))
+	for i := len(q) - 1; i >= 0; i-- {
+		header = append(header, q[i])
+	}
+	header = binary.LittleEndian.AppendUint32(header, uint32(power))
+	header = binary.LittleEndian.AppendUint32(header, 28)
+
+	var tauG1, tauG2 []byte
+	for i := range g1 {
+		tauG1 = appendPtauElement(tauG1, &g1[i].X)
+		tauG1 = appendPtauElement(tauG1, &g1[i].Y)
+	}
+	for i := range g2 {
+		tauG2 = appendPtauElement(tauG2, &g2[i].X.A0)
+		tauG2 = appendPtauElement(tauG2, &g2[i].X.A1)
+		tauG2 = appendPtauElement(tauG2, &g2[i].Y.A0)
+		tauG2 = appendPtauElement(tauG2, &g2[i].Y.A1)
+	}
+
+	buf := []byte(ptauMagic)
+	buf = binary.LittleEndian.AppendUint32(buf, 1)
+	buf = binary.LittleEndian.AppendUint32(buf, 3)
+	buf = appendPtauSection(buf, ptauSectionHeader, header)
+	buf = appendPtauSection(buf, ptauSectionTauG1, tauG1)
+	buf = appendPtauSection(buf, ptauSectionTauG2, tauG2)
+	err = os.WriteFile(fn, buf, 0644)
+	assert.NoError(t, err)
+}
+
+func TestImportPtau(t *testing.T) {
+	dir := t.TempDir()
+	ptauFile := filepath.Join(dir, "test_5.ptau")
+	writePtau(t, ptauFile, 5, toxicValue, nil)
+
+	srsDir := t.TempDir()
+	source, err := ImportPtau(ptauFile, srsDir, 4)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, source.Power)
+	assert.Equal(t, 28, source.CeremonyPower)
+	size, hash, err := hashFile(ptauFile)
+	assert.NoError(t, err)
+	assert.Equal(t, size, source.Size)
+	assert.Equal(t, hash, source.Sha256)
+
+	recorded, err := ReadSrsSource(srsDir, ecc.BN254, 4)
+	assert.NoError(t, err)
+	assert.Equal(t, source.Sha256, recorded.Sha256)
+
+	// the same SRS as generated from the toxic value
+	srs, lsrs, err := GenerateUnsafeSrs(ecc.BN254, 4, toxicValue)
+	assert.NoError(t, err)
+	imported, importedLagrange, err := ReadSrs(1<<4, srsDir)
+	assert.NoError(t, err)
+	assert.True(t, bytes.Equal(encoding(t, srs), encoding(t, *imported)))
+	assert.True(t, bytes.Equal(encoding(t, lsrs), encoding(t, *importedLagrange)))
+
+	_, err = ImportPtau(ptauFile, srsDir, 6)
+	assert.ErrorIs(t, err, ErrInvalidTranscript)
+}
+
+func TestImportPtau_Invalid(t *testing.T) {
+	for name, tamper := range map[string]func(g1 []bn254.G1Affine, g2 []bn254.G2Affine){
+		"power": func(g1 []bn254.G1Affine, g2 []bn254.G2Affine) {
+			g1[7].Double(&g1[7])
+		},
+		"tau": func(g1 []bn254.G1Affine, g2 []bn254.G2Affine) {
+			g2[1].Double(&g2[1])
+		},
+		"generator": func(g1 []bn254.G1Affine, g2 []bn254.G2Affine) {
+			g1[0].Double(&g1[0])
+		},
+		"curve": func(g1 []bn254.G1Affine, g2 []bn254.G2Affine) {
+			g1[3].Y.SetOne()
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			ptauFile := filepath.Join(t.TempDir(), "test_4.ptau")
+			writePtau(t, ptauFile, 4, toxicValue, tamper)
+			srsDir := t.TempDir()
+			_, err := ImportPtau(ptauFile, srsDir, 3)
+			assert.ErrorIs(t, err, ErrInvalidTranscript)
+			infos, err := ListSrs(srsDir)
+			assert.NoError(t, err)
+			assert.Empty(t, infos)
+		})
+	}
+}
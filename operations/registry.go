@@ -0,0 +1,165 @@
+package operations
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/lightec-xyz/common/utils"
+)
+
+var (
+	ErrNotRegistered        = errors.New("circuit not registered")
+	ErrDuplicateName        = errors.New("circuit name already registered")
+	ErrDuplicateFingerPrint = errors.New("vk fingerprint already registered")
+)
+
+// Registry indexes circuits by name and by the UnsafeFingerPrint of their vk, e.g. to find the
+// inner circuit referenced by a recursive proof. Only the vk is read on registration, the ccs and
+// pk are loaded on the first Get. It is safe for concurrent use.
+type Registry struct {
+	opts []Option
+
+	lock   sync.RWMutex
+	byName map[string]*registryEntry
+	byFp   map[string]*registryEntry
+	names  []string // registration order
+}
+
+type registryEntry struct {
+	ops *CircuitOperations
+	fp  utils.FingerPrintBytes
+
+	lock   sync.Mutex
+	loaded bool
+}
+
+// NewRegistry returns an empty registry, opts are passed to the circuits it creates
+func NewRegistry(opts ...Option) *Registry {
+	return &Registry{
+		opts:   opts,
+		byName: make(map[string]*registryEntry),
+		byFp:   make(map[string]*registryEntry),
+	}
+}
+
+// Register adds the circuit name described by config
+func (r *Registry) Register(name string, config *Config) (*CircuitOperations, error) {
+	ops := NewCircuitOperations(config, name, r.opts...)
+	err := r.RegisterOperations(ops)
+	if err != nil {
+		return nil, err
+	}
+	return ops, nil
+}
+
+// RegisterOperations adds ops under its ComponentName, ops is loaded by Get unless it already has its keys.
+func (r *Registry) RegisterOperations(ops *CircuitOperations) error {
+	fp, err := ops.UnsafeFingerPrint()
+	if err != nil {
+		return fmt.Errorf("register %v: %w", ops.ComponentName, err)
+	}
+	entry := &registryEntry{
+		ops:    ops,
+		fp:     fp,
+		loaded: ops.Ccs != nil && ops.ProvingKey != nil,
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if _, ok := r.byName[ops.ComponentName]; ok {
+		return fmt.Errorf("register %v: %w", ops.ComponentName, ErrDuplicateName)
+	}
+	if other, ok := r.byFp[string(fp)]; ok {
+		return fmt.Errorf("register %v: %w by %v", ops.ComponentName, ErrDuplicateFingerPrint, other.ops.ComponentName)
+	}
+	r.byName[ops.ComponentName] = entry
+	r.byFp[string(fp)] = entry
+	r.names = append(r.names, ops.ComponentName)
+	return nil
+}
+
+// Get returns the circuit name, loading its ccs, pk and vk first if needed
+func (r *Registry) Get(name string) (*CircuitOperations, error) {
+	r.lock.RLock()
+	entry, ok := r.byName[name]
+	r.lock.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%v: %w", name, ErrNotRegistered)
+	}
+	return entry.load()
+}
+
+// GetByFingerPrint is Get for the circuit whose vk has fingerprint fp
+func (r *Registry) GetByFingerPrint(fp []byte) (*CircuitOperations, error) {
+	r.lock.RLock()
+	entry, ok := r.byFp[string(fp)]
+	r.lock.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("fingerprint %x: %w", fp, ErrNotRegistered)
+	}
+	return entry.load()
+}
+
+// FingerPrint returns the vk fingerprint of the circuit name, without loading it
+func (r *Registry) FingerPrint(name string) (utils.FingerPrintBytes, error) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	entry, ok := r.byName[name]
+	if !ok {
+		return nil, fmt.Errorf("%v: %w", name, ErrNotRegistered)
+	}
+	return entry.fp, nil
+}
+
+// FingerPrints returns the fingerprints of the circuits names, or of every circuit in registration order
+// if names is empty, e.g. as the allowed set of utils.AssertFpInSet.
+func (r *Registry) FingerPrints(names ...string) ([]utils.FingerPrintBytes, error) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	if len(names) == 0 {
+		names = r.names
+	}
+	fps := make([]utils.FingerPrintBytes, 0, len(names))
+	for _, name := range names {
+		entry, ok := r.byName[name]
+		if !ok {
+			return nil, fmt.Errorf("%v: %w", name, ErrNotRegistered)
+		}
+		fps = append(fps, entry.fp)
+	}
+	return fps, nil
+}
+
+// Names returns the registered names, sorted
+func (r *Registry) Names() []string {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	names := append([]string(nil), r.names...)
+	sort.Strings(names)
+	return names
+}
+
+// load loads the circuit once, checking that its vk is still the registered one
+func (e *registryEntry) load() (*CircuitOperations, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	if e.loaded {
+		return e.ops, nil
+	}
+	err := e.ops.LoadCcsPkVk()
+	if err != nil {
+		return nil, err
+	}
+	fp, err := e.ops.UnsafeFingerPrint()
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(fp, e.fp) {
+		return nil, fmt.Errorf("%v: vk fingerprint changed since registration, %x instead of %x", e.ops.ComponentName, fp, e.fp)
+	}
+	e.loaded = true
+	return e.ops, nil
+}
@@ -0,0 +1,59 @@
+package operations
+
+import (
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry(t *testing.T) {
+	bn254Dir, bls12377Dir, copyDir := t.TempDir(), t.TempDir(), t.TempDir()
+	assert.NoError(t, SetupCubicCircuit(bn254Dir))
+	assert.NoError(t, SetupCubicCircuit(copyDir))
+	writeUnsafeSrs(t, bls12377Dir, ecc.BLS12_377)
+	config := NewCubicConfig(bls12377Dir, bls12377Dir, bls12377Dir)
+	config.Curve = ecc.BLS12_377
+	circuit, _ := NewCubicCircuit()
+	assert.NoError(t, NewCircuitOperations(config, "setup").SetupAndSaveCcsPkVk(circuit))
+
+	registry := NewRegistry(WithCache(nil))
+	inner, err := registry.Register("inner", NewCubicConfig(bn254Dir, "", bn254Dir))
+	assert.NoError(t, err)
+	assert.Nil(t, inner.ProvingKey, "loaded lazily")
+	_, err = registry.Register("outer", NewCubicConfig(bls12377Dir, "", bls12377Dir))
+	assert.NoError(t, err)
+
+	_, err = registry.Register("inner", NewCubicConfig(bls12377Dir, "", bls12377Dir))
+	assert.ErrorIs(t, err, ErrDuplicateName)
+	_, err = registry.Register("copy", NewCubicConfig(copyDir, "", copyDir))
+	assert.ErrorIs(t, err, ErrDuplicateFingerPrint)
+	assert.Equal(t, []string{"inner", "outer"}, registry.Names())
+
+	fp, err := registry.FingerPrint("inner")
+	assert.NoError(t, err)
+	ops, err := registry.GetByFingerPrint(fp)
+	assert.NoError(t, err)
+	assert.True(t, ops == inner)
+	assert.NotNil(t, ops.ProvingKey)
+	assignment, _ := NewCubicCircuitAssignment(3, 38)
+	_, err = ops.ProveWithAssignment(assignment, true)
+	assert.NoError(t, err)
+
+	ops, err = registry.Get("outer")
+	assert.NoError(t, err)
+	curve, err := CurveOf(ops.VerifyingKey)
+	assert.NoError(t, err)
+	assert.Equal(t, ecc.BLS12_377, curve)
+
+	fps, err := registry.FingerPrints()
+	assert.NoError(t, err)
+	assert.Len(t, fps, 2)
+	assert.Equal(t, fp, fps[0])
+	_, err = registry.FingerPrints("inner", "copy")
+	assert.ErrorIs(t, err, ErrNotRegistered)
+	_, err = registry.Get("copy")
+	assert.ErrorIs(t, err, ErrNotRegistered)
+	_, err = registry.GetByFingerPrint([]byte{1})
+	assert.ErrorIs(t, err, ErrNotRegistered)
+}
@@ -0,0 +1,217 @@
+package operations
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	fr_bn254 "github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	plonk_bn254 "github.com/consensys/gnark/backend/plonk/bn254"
+	"github.com/consensys/gnark/backend/witness"
+	"golang.org/x/crypto/sha3"
+)
+
+// SolidityVerifyFunction is the function of the verifier exported by WriteVkInSolidity called by the calldata of
+// a SolidityBundle
+const SolidityVerifyFunction = "Verify(bytes,uint256[])"
+
+var ErrInvalidSolidityBundle = errors.New("invalid solidity bundle")
+
+// SolidityBundle is what a relayer needs to submit a bn254 PLONK proof to the verifier exported by
+// WriteVkInSolidity. Byte strings are 0x prefixed hex.
+type SolidityBundle struct {
+	Function      string   `json:"function"`
+	Calldata      string   `json:"calldata"`      // selector and ABI encoded arguments of Function
+	Proof         string   `json:"proof"`         // MarshalSolidity, the bytes argument
+	PublicInputs  []string `json:"publicInputs"`  // the uint256[] argument, 32 bytes each
+	VkFingerPrint string   `json:"vkFingerPrint"` // see UnsafeFingerPrintFromVk
+	GnarkProof    string   `json:"gnarkProof"`    // the proof as written by WriteProof, for VerifySolidityBundle
+}
+
+// NewSolidityBundle returns the SolidityBundle of proof, a bn254 PLONK proof verified by vk
+func NewSolidityBundle(proof *Proof, vk VerifyingKey) (*SolidityBundle, error) {
+	_proof, ok := proof.Proof.(*plonk_bn254.Proof)
+	if !ok {
+		return nil, fmt.Errorf("solidity export is only supported for bn254 PLONK proofs, got %T", proof.Proof)
+	}
+	pubWit, err := proof.Witness.Public()
+	if err != nil {
+		return nil, err
+	}
+	inputs, ok := pubWit.Vector().(fr_bn254.Vector)
+	if !ok {
+		return nil, fmt.Errorf("bn254 proof with a %T witness", pubWit.Vector())
+	}
+	fp, err := UnsafeFingerPrintFromVk(vk)
+	if err != nil {
+		return nil, err
+	}
+	var gnarkProof bytes.Buffer
+	_, err = _proof.WriteTo(&gnarkProof)
+	if err != nil {
+		return nil, err
+	}
+
+	solidityProof := _proof.MarshalSolidity()
+	publicInputs := make([][32]byte, len(inputs))
+	bundle := &SolidityBundle{
+		Function:      SolidityVerifyFunction,
+		Proof:         hexBytes(solidityProof),
+		PublicInputs:  make([]string, len(inputs)),
+		VkFingerPrint: hexBytes(fp),
+		GnarkProof:    hexBytes(gnarkProof.Bytes()),
+	}
+	for i := range inputs {
+		publicInputs[i] = inputs[i].Bytes()
+		bundle.PublicInputs[i] = hexBytes(publicInputs[i][:])
+	}
+	bundle.Calldata = hexBytes(encodeSolidityVerify(solidityProof, publicInputs))
+	return bundle, nil
+}
+
+// VerifySolidityBundle checks that bundle is consistent and that its proof is verified by vk: the vk fingerprint,
+// the calldata made of the proof and public inputs and the solidity encoding of the gnark proof must all match.
+func VerifySolidityBundle(bundle *SolidityBundle, vk VerifyingKey) error {
+	fp, err := UnsafeFingerPrintFromVk(vk)
+	if err != nil {
+		return err
+	}
+	if bundle.VkFingerPrint != hexBytes(fp) {
+		return fmt.Errorf("%w: vk fingerprint %v, %v expected", ErrInvalidSolidityBundle, bundle.VkFingerPrint, hexBytes(fp))
+	}
+	if bundle.Function != SolidityVerifyFunction {
+		return fmt.Errorf("%w: function %v", ErrInvalidSolidityBundle, bundle.Function)
+	}
+
+	solidityProof, err := parseHexBytes(bundle.Proof)
+	if err != nil {
+		return err
+	}
+	publicInputs := make([][32]byte, len(bundle.PublicInputs))
+	values := make(chan any, len(bundle.PublicInputs))
+	for i, s := range bundle.PublicInputs {
+		b, err := parseHexBytes(s)
+		if err != nil {
+			return err
+		}
+		if len(b) != 32 {
+			return fmt.Errorf("%w: public input %v of %v bytes", ErrInvalidSolidityBundle, i, len(b))
+		}
+		copy(publicInputs[i][:], b)
+		var e fr_bn254.Element
+		err = e.SetBytesCanonical(b)
+		if err != nil {
+			return fmt.Errorf("%w: public input %v: %v", ErrInvalidSolidityBundle, i, err)
+		}
+		values <- e
+	}
+	close(values)
+	calldata, err := parseHexBytes(bundle.Calldata)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(calldata, encodeSolidityVerify(solidityProof, publicInputs)) {
+		return fmt.Errorf("%w: calldata does not match the proof and public inputs", ErrInvalidSolidityBundle)
+	}
+
+	gnarkProof, err := parseHexBytes(bundle.GnarkProof)
+	if err != nil {
+		return err
+	}
+	proof := &plonk_bn254.Proof{}
+	_, err = proof.ReadFrom(bytes.NewReader(gnarkProof))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSolidityBundle, err)
+	}
+	if !bytes.Equal(proof.MarshalSolidity(), solidityProof) {
+		return fmt.Errorf("%w: proof is not the solidity encoding of the gnark proof", ErrInvalidSolidityBundle)
+	}
+
+	pubWit, err := witness.New(ecc.BN254.ScalarField())
+	if err != nil {
+		return err
+	}
+	err = pubWit.Fill(len(publicInputs), 0, values)
+	if err != nil {
+		return err
+	}
+	return PlonkVerify(vk, proof, pubWit, true)
+}
+
+// WriteSolidityBundle writes the SolidityBundle of proof as JSON
+func WriteSolidityBundle(proof *Proof, vk VerifyingKey, fn string) error {
+	bundle, err := NewSolidityBundle(proof, vk)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		return err
+	}
+	return WriteFileAtomic(fn, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
+
+// ReadSolidityBundle reads a bundle written by WriteSolidityBundle
+func ReadSolidityBundle(fn string) (*SolidityBundle, error) {
+	data, err := os.ReadFile(fn)
+	if err != nil {
+		return nil, err
+	}
+	bundle := &SolidityBundle{}
+	err = json.Unmarshal(data, bundle)
+	if err != nil {
+		return nil, fmt.Errorf("read %v: %w", fn, err)
+	}
+	return bundle, nil
+}
+
+// encodeSolidityVerify returns the calldata of SolidityVerifyFunction: its selector, the offsets of the dynamic
+// arguments, the proof length and bytes right padded to 32 bytes, then the number of inputs and the inputs.
+func encodeSolidityVerify(proof []byte, publicInputs [][32]byte) []byte {
+	h := sha3.NewLegacyKeccak256()
+	h.Write([]byte(SolidityVerifyFunction))
+	calldata := h.Sum(nil)[:4]
+
+	paddedLen := (len(proof) + 31) / 32 * 32
+	calldata = append(calldata, abiWord(64)...)
+	calldata = append(calldata, abiWord(64+32+paddedLen)...)
+	calldata = append(calldata, abiWord(len(proof))...)
+	calldata = append(calldata, proof...)
+	calldata = append(calldata, make([]byte, paddedLen-len(proof))...)
+	calldata = append(calldata, abiWord(len(publicInputs))...)
+	for i := range publicInputs {
+		calldata = append(calldata, publicInputs[i][:]...)
+	}
+	return calldata
+}
+
+func abiWord(n int) []byte {
+	word := make([]byte, 32)
+	binary.BigEndian.PutUint64(word[24:], uint64(n))
+	return word
+}
+
+func hexBytes(b []byte) string {
+	return "0x" + hex.EncodeToString(b)
+}
+
+func parseHexBytes(s string) ([]byte, error) {
+	if !strings.HasPrefix(s, "0x") {
+		return nil, fmt.Errorf("%w: %q is not 0x prefixed", ErrInvalidSolidityBundle, s)
+	}
+	b, err := hex.DecodeString(s[2:])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSolidityBundle, err)
+	}
+	return b, nil
+}
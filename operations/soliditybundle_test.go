@@ -0,0 +1,67 @@
+package operations
+
+import (
+	"encoding/binary"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSolidityBundle(t *testing.T) {
+	dir := t.TempDir()
+	err := SetupCubicCircuit(dir)
+	assert.NoError(t, err)
+	instance := NewCubic(NewCubicConfig(dir, "", dir))
+	err = instance.Load()
+	assert.NoError(t, err)
+	assignment, _ := NewCubicCircuitAssignment(3, 38)
+	proof, err := instance.ProveWithAssignment(assignment, true)
+	assert.NoError(t, err)
+
+	fn := filepath.Join(dir, "bundle.json")
+	err = WriteSolidityBundle(proof, instance.VerifyingKey, fn)
+	assert.NoError(t, err)
+	bundle, err := ReadSolidityBundle(fn)
+	assert.NoError(t, err)
+	err = VerifySolidityBundle(bundle, instance.VerifyingKey)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"0x0000000000000000000000000000000000000000000000000000000000000026"}, bundle.PublicInputs)
+
+	// selector, offsets of the proof and the inputs, the proof, then the inputs
+	calldata, err := parseHexBytes(bundle.Calldata)
+	assert.NoError(t, err)
+	solidityProof, err := parseHexBytes(bundle.Proof)
+	assert.NoError(t, err)
+	word := func(i int) uint64 {
+		return binary.BigEndian.Uint64(calldata[4+32*i+24:])
+	}
+	assert.Equal(t, uint64(64), word(0))
+	assert.Equal(t, uint64(len(solidityProof)), word(2))
+	assert.Equal(t, solidityProof, calldata[4+96:4+96+len(solidityProof)])
+	inputs := int(word(1) / 32)
+	assert.Equal(t, uint64(1), word(inputs))
+	assert.Equal(t, uint64(38), word(inputs+1))
+	assert.Equal(t, 4+32*(inputs+2), len(calldata))
+
+	tampered := *bundle
+	tampered.PublicInputs = []string{"0x" + strings.Repeat("0", 62) + "27"}
+	err = VerifySolidityBundle(&tampered, instance.VerifyingKey)
+	assert.ErrorIs(t, err, ErrInvalidSolidityBundle)
+
+	// consistent, but not a valid proof of the inputs
+	tampered.Calldata = hexBytes(encodeSolidityVerify(solidityProof, [][32]byte{{31: 0x27}}))
+	err = VerifySolidityBundle(&tampered, instance.VerifyingKey)
+	assert.Error(t, err)
+
+	tampered = *bundle
+	tampered.VkFingerPrint = "0x00"
+	err = VerifySolidityBundle(&tampered, instance.VerifyingKey)
+	assert.ErrorIs(t, err, ErrInvalidSolidityBundle)
+
+	tampered = *bundle
+	tampered.Proof = "0x" + strings.Repeat("00", len(solidityProof))
+	err = VerifySolidityBundle(&tampered, instance.VerifyingKey)
+	assert.ErrorIs(t, err, ErrInvalidSolidityBundle)
+}
@@ -0,0 +1,116 @@
+package operations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/consensys/gnark/backend/witness"
+	"github.com/consensys/gnark/constraint"
+	cs_bls12377 "github.com/consensys/gnark/constraint/bls12-377"
+	cs_bls12381 "github.com/consensys/gnark/constraint/bls12-381"
+	cs_bn254 "github.com/consensys/gnark/constraint/bn254"
+	cs_bw6761 "github.com/consensys/gnark/constraint/bw6-761"
+	"github.com/consensys/gnark/frontend"
+)
+
+// UnsatisfiedError is returned by Solve when the assignment does not satisfy the circuit.
+// Constraint is -1 when the solver failed before reaching a constraint, e.g. on a missing hint.
+type UnsatisfiedError struct {
+	Constraint int
+	DebugInfo  string // the failing constraint with its values, empty if gnark recorded none
+	Err        error
+}
+
+func (e *UnsatisfiedError) Error() string {
+	return fmt.Sprintf("unsatisfied assignment: %v", e.Err)
+}
+
+func (e *UnsatisfiedError) Unwrap() error {
+	return e.Err
+}
+
+// Solve builds the full witness of assignment and runs the solver of ccs on it, without proving. It is cheap
+// compared to proving and reports a *UnsatisfiedError with the failing constraint when the assignment does
+// not satisfy the circuit. The witness can then be proven by ProveWitness.
+func Solve(ccs constraint.ConstraintSystem, assignment frontend.Circuit) (witness.Witness, error) {
+	return SolveCtx(context.Background(), ccs, assignment)
+}
+
+// SolveCtx is Solve returning a *CanceledError once ctx is done.
+func SolveCtx(ctx context.Context, ccs constraint.ConstraintSystem, assignment frontend.Circuit) (witness.Witness, error) {
+	wit, err := newWitnessCtx(ctx, ccs, assignment)
+	if err != nil {
+		return nil, err
+	}
+	_, err = runWithContext(ctx, PhaseSolve, func() (any, error) {
+		return ccs.Solve(wit)
+	})
+	if err != nil {
+		return nil, unsatisfiedError(err)
+	}
+	return wit, nil
+}
+
+// newWitnessCtx builds the full witness of assignment on the curve of ccs
+func newWitnessCtx(ctx context.Context, ccs constraint.ConstraintSystem, assignment frontend.Circuit) (witness.Witness, error) {
+	curve, err := CurveOf(ccs)
+	if err != nil {
+		return nil, err
+	}
+	return runWithContext(ctx, PhaseWitness, func() (witness.Witness, error) {
+		return frontend.NewWitness(assignment, curve.ScalarField())
+	})
+}
+
+// unsatisfiedError wraps the solver error err in an *UnsatisfiedError, keeping the failing constraint of the
+// curve specific gnark error. Cancellation is returned as is.
+func unsatisfiedError(err error) error {
+	var canceled *CanceledError
+	if errors.As(err, &canceled) {
+		return err
+	}
+	var (
+		cid       = -1
+		debugInfo *string
+	)
+	switch e := err.(type) {
+	case *cs_bn254.UnsatisfiedConstraintError:
+		cid, debugInfo = e.CID, e.DebugInfo
+	case *cs_bls12377.UnsatisfiedConstraintError:
+		cid, debugInfo = e.CID, e.DebugInfo
+	case *cs_bls12381.UnsatisfiedConstraintError:
+		cid, debugInfo = e.CID, e.DebugInfo
+	case *cs_bw6761.UnsatisfiedConstraintError:
+		cid, debugInfo = e.CID, e.DebugInfo
+	}
+	unsatisfied := &UnsatisfiedError{Constraint: cid, Err: err}
+	if debugInfo != nil {
+		unsatisfied.DebugInfo = *debugInfo
+	}
+	return unsatisfied
+}
+
+// Solve is the package level Solve with the loaded ccs
+func (c *CircuitOperations) Solve(assignment frontend.Circuit) (witness.Witness, error) {
+	return c.SolveCtx(context.Background(), assignment)
+}
+
+// SolveCtx is Solve returning a *CanceledError once ctx is done.
+func (c *CircuitOperations) SolveCtx(ctx context.Context, assignment frontend.Circuit) (witness.Witness, error) {
+	if c.Ccs == nil {
+		return nil, fmt.Errorf("%v: ccs must be loaded before solving", c.ComponentName)
+	}
+	wit, err := SolveCtx(ctx, c.Ccs, assignment)
+	if err != nil {
+		c.Logger.Error().Msgf("failed to solve %v: %v", c.ComponentName, err)
+		return nil, err
+	}
+	return wit, nil
+}
+
+// CheckAssignment returns a *UnsatisfiedError if assignment does not satisfy the loaded ccs, see Solve
+func (c *CircuitOperations) CheckAssignment(assignment frontend.Circuit) error {
+	_, err := c.Solve(assignment)
+	return err
+}
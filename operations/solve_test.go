@@ -0,0 +1,55 @@
+package operations
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSolve(t *testing.T) {
+	dir := t.TempDir()
+	err := SetupCubicCircuit(dir)
+	assert.NoError(t, err)
+	config := NewCubicConfig(dir, "", dir)
+	instance := NewCircuitOperations(config, "cubic")
+
+	assignment, _ := NewCubicCircuitAssignment(3, 38)
+	err = instance.CheckAssignment(assignment)
+	assert.Error(t, err)
+
+	err = instance.LoadCcsPkVk()
+	assert.NoError(t, err)
+	err = instance.CheckAssignment(assignment)
+	assert.NoError(t, err)
+
+	unsatisfiable, _ := NewCubicCircuitAssignment(3, 39)
+	_, err = instance.Solve(unsatisfiable)
+	var unsatisfied *UnsatisfiedError
+	assert.True(t, errors.As(err, &unsatisfied))
+	assert.GreaterOrEqual(t, unsatisfied.Constraint, 0)
+	assert.Contains(t, err.Error(), "is not satisfied")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = instance.SolveCtx(ctx, unsatisfiable)
+	var canceled *CanceledError
+	assert.True(t, errors.As(err, &canceled))
+	assert.False(t, errors.As(err, &unsatisfied))
+
+	// the solved witness is proven as is, verified or not
+	wit, err := instance.Solve(assignment)
+	assert.NoError(t, err)
+	proof, err := instance.ProveWitness(wit, true)
+	assert.NoError(t, err)
+	assert.Equal(t, wit, proof.Witness)
+
+	config.SkipVerify = true
+	proof, err = instance.ProveWithAssignment(assignment, true)
+	assert.NoError(t, err)
+	err = PlonkVerify(instance.VerifyingKey, proof.Proof, proof.Witness, true)
+	assert.NoError(t, err)
+	_, err = instance.ProveWithAssignment(unsatisfiable, true)
+	assert.Error(t, err)
+}
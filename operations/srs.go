@@ -0,0 +1,297 @@
+package operations
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	kzg_bls12377 "github.com/consensys/gnark-crypto/ecc/bls12-377/kzg"
+	kzg_bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381/kzg"
+	kzg_bn254 "github.com/consensys/gnark-crypto/ecc/bn254/kzg"
+	kzg_bw6761 "github.com/consensys/gnark-crypto/ecc/bw6-761/kzg"
+	"github.com/consensys/gnark-crypto/kzg"
+)
+
+// SRS files are named <curve>_pow_<power>.srs for the canonical SRS, holding 2^power+3 G1 points,
+// and <curve>_pow_<power>.lsrs for the Lagrange one, holding 2^power G1 points. See ReadSrsWithCurve.
+
+var srsFileRegexp = regexp.MustCompile(`^([a-z0-9_]+)_pow_(\d+)\.(srs|lsrs)$`)
+
+// SrsFiles returns the canonical and Lagrange SRS files of power in srsDir
+func SrsFiles(srsDir string, curve ecc.ID, power int) (srsFile string, lagrangeSrsFile string) {
+	srsFile = filepath.Join(srsDir, fmt.Sprintf("%v_pow_%v.srs", curve, power))
+	lagrangeSrsFile = filepath.Join(srsDir, fmt.Sprintf("%v_pow_%v.lsrs", curve, power))
+	return srsFile, lagrangeSrsFile
+}
+
+// GenerateUnsafeSrs returns a canonical and Lagrange SRS for circuits up to 2^power constraints,
+// from a toxic value derived from seed (random if nil). Only use it for development and tests.
+func GenerateUnsafeSrs(curve ecc.ID, power int, seed []byte) (kzg.SRS, kzg.SRS, error) {
+	err := checkCurve(curve)
+	if err != nil {
+		return nil, nil, err
+	}
+	var tau *big.Int
+	if seed == nil {
+		tau, err = rand.Int(rand.Reader, curve.ScalarField())
+		if err != nil {
+			return nil, nil, err
+		}
+	} else {
+		// as unsafekzg.WithToxicSeed
+		h := sha256.Sum256(seed)
+		tau = new(big.Int).SetBytes(h[:])
+	}
+
+	size := uint64(1)<<power + 3
+	var srs kzg.SRS
+	switch curve {
+	case ecc.BN254:
+		srs, err = kzg_bn254.NewSRS(size, tau)
+	case ecc.BLS12_377:
+		srs, err = kzg_bls12377.NewSRS(size, tau)
+	case ecc.BLS12_381:
+		srs, err = kzg_bls12381.NewSRS(size, tau)
+	case ecc.BW6_761:
+		srs, err = kzg_bw6761.NewSRS(size, tau)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	lsrs, err := LagrangeSrs(srs, power)
+	if err != nil {
+		return nil, nil, err
+	}
+	return srs, lsrs, nil
+}
+
+// LagrangeSrs derives the Lagrange SRS of size 2^power from a canonical SRS holding at least 2^power points
+func LagrangeSrs(canonical kzg.SRS, power int) (kzg.SRS, error) {
+	n := 1 << power
+	switch srs := canonical.(type) {
+	case *kzg_bn254.SRS:
+		if len(srs.Pk.G1) < n {
+			return nil, errSrsTooSmall(len(srs.Pk.G1), n)
+		}
+		g1, err := kzg_bn254.ToLagrangeG1(srs.Pk.G1[:n])
+		if err != nil {
+			return nil, err
+		}
+		return &kzg_bn254.SRS{Pk: kzg_bn254.ProvingKey{G1: g1}, Vk: srs.Vk}, nil
+	case *kzg_bls12377.SRS:
+		if len(srs.Pk.G1) < n {
+			return nil, errSrsTooSmall(len(srs.Pk.G1), n)
+		}
+		g1, err := kzg_bls12377.ToLagrangeG1(srs.Pk.G1[:n])
+		if err != nil {
+			return nil, err
+		}
+		return &kzg_bls12377.SRS{Pk: kzg_bls12377.ProvingKey{G1: g1}, Vk: srs.Vk}, nil
+	case *kzg_bls12381.SRS:
+		if len(srs.Pk.G1) < n {
+			return nil, errSrsTooSmall(len(srs.Pk.G1), n)
+		}
+		g1, err := kzg_bls12381.ToLagrangeG1(srs.Pk.G1[:n])
+		if err != nil {
+			return nil, err
+		}
+		return &kzg_bls12381.SRS{Pk: kzg_bls12381.ProvingKey{G1: g1}, Vk: srs.Vk}, nil
+	case *kzg_bw6761.SRS:
+		if len(srs.Pk.G1) < n {
+			return nil, errSrsTooSmall(len(srs.Pk.G1), n)
+		}
+		g1, err := kzg_bw6761.ToLagrangeG1(srs.Pk.G1[:n])
+		if err != nil {
+			return nil, err
+		}
+		return &kzg_bw6761.SRS{Pk: kzg_bw6761.ProvingKey{G1: g1}, Vk: srs.Vk}, nil
+	default:
+		return nil, fmt.Errorf("unknown srs type %T", canonical)
+	}
+}
+
+// TrimSrs returns the canonical SRS of power made of a copy of the first points of a larger canonical SRS
+func TrimSrs(canonical kzg.SRS, power int) (kzg.SRS, error) {
+	n := 1<<power + 3
+	switch srs := canonical.(type) {
+	case *kzg_bn254.SRS:
+		if len(srs.Pk.G1) < n {
+			return nil, errSrsTooSmall(len(srs.Pk.G1), n)
+		}
+		return &kzg_bn254.SRS{Pk: kzg_bn254.ProvingKey{G1: slices.Clone(srs.Pk.G1[:n])}, Vk: srs.Vk}, nil
+	case *kzg_bls12377.SRS:
+		if len(srs.Pk.G1) < n {
+			return nil, errSrsTooSmall(len(srs.Pk.G1), n)
+		}
+		return &kzg_bls12377.SRS{Pk: kzg_bls12377.ProvingKey{G1: slices.Clone(srs.Pk.G1[:n])}, Vk: srs.Vk}, nil
+	case *kzg_bls12381.SRS:
+		if len(srs.Pk.G1) < n {
+			return nil, errSrsTooSmall(len(srs.Pk.G1), n)
+		}
+		return &kzg_bls12381.SRS{Pk: kzg_bls12381.ProvingKey{G1: slices.Clone(srs.Pk.G1[:n])}, Vk: srs.Vk}, nil
+	case *kzg_bw6761.SRS:
+		if len(srs.Pk.G1) < n {
+			return nil, errSrsTooSmall(len(srs.Pk.G1), n)
+		}
+		return &kzg_bw6761.SRS{Pk: kzg_bw6761.ProvingKey{G1: slices.Clone(srs.Pk.G1[:n])}, Vk: srs.Vk}, nil
+	default:
+		return nil, fmt.Errorf("unknown srs type %T", canonical)
+	}
+}
+
+func errSrsTooSmall(have, need int) error {
+	return fmt.Errorf("srs has %v G1 points, %v needed", have, need)
+}
+
+// ReadSrsFile reads a canonical or Lagrange SRS file on curve
+func ReadSrsFile(fn string, curve ecc.ID) (kzg.SRS, error) {
+	err := checkCurve(curve)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(fn)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	srs := kzg.NewSRS(curve)
+	_, err = srs.ReadFrom(f)
+	if err != nil {
+		return nil, fmt.Errorf("read %v: %w", fn, err)
+	}
+	return srs, nil
+}
+
+// WriteSrs writes canonical and, if not nil, lagrange as the SRS files of power in srsDir
+func WriteSrs(srsDir string, power int, canonical, lagrange kzg.SRS) error {
+	curve, err := srsCurve(canonical)
+	if err != nil {
+		return err
+	}
+	srsFile, lagrangeSrsFile := SrsFiles(srsDir, curve, power)
+	err = WriteToFileAtomic(canonical, srsFile)
+	if err != nil {
+		return err
+	}
+	if lagrange == nil {
+		return nil
+	}
+	return WriteToFileAtomic(lagrange, lagrangeSrsFile)
+}
+
+func srsCurve(srs kzg.SRS) (ecc.ID, error) {
+	switch srs.(type) {
+	case *kzg_bn254.SRS:
+		return ecc.BN254, nil
+	case *kzg_bls12377.SRS:
+		return ecc.BLS12_377, nil
+	case *kzg_bls12381.SRS:
+		return ecc.BLS12_381, nil
+	case *kzg_bw6761.SRS:
+		return ecc.BW6_761, nil
+	default:
+		return ecc.UNKNOWN, fmt.Errorf("unknown srs type %T", srs)
+	}
+}
+
+// SrsInfo describes an SRS file, NbG1 is only set by InspectSrs
+type SrsInfo struct {
+	File     string
+	Curve    ecc.ID
+	Power    int
+	Lagrange bool
+	Size     int64
+	NbG1     int
+}
+
+// ListSrs returns the SRS files of srsDir, ordered by curve, power and kind, from their names only
+func ListSrs(srsDir string) ([]SrsInfo, error) {
+	entries, err := os.ReadDir(srsDir)
+	if err != nil {
+		return nil, err
+	}
+	var infos []SrsInfo
+	for _, entry := range entries {
+		m := srsFileRegexp.FindStringSubmatch(entry.Name())
+		if m == nil || entry.IsDir() {
+			continue
+		}
+		curve, err := ecc.IDFromString(m[1])
+		if err != nil {
+			continue
+		}
+		power, err := strconv.Atoi(m[2])
+		if err != nil {
+			continue
+		}
+		fi, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, SrsInfo{
+			File:     filepath.Join(srsDir, entry.Name()),
+			Curve:    curve,
+			Power:    power,
+			Lagrange: m[3] == "lsrs",
+			Size:     fi.Size(),
+		})
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		a, b := infos[i], infos[j]
+		if a.Curve != b.Curve {
+			return a.Curve < b.Curve
+		}
+		if a.Power != b.Power {
+			return a.Power < b.Power
+		}
+		return !a.Lagrange && b.Lagrange
+	})
+	return infos, nil
+}
+
+// InspectSrs reads the SRS file fn, named as described above, and checks its number of points
+func InspectSrs(fn string) (*SrsInfo, error) {
+	m := srsFileRegexp.FindStringSubmatch(filepath.Base(fn))
+	if m == nil {
+		return nil, fmt.Errorf("%v is not named <curve>_pow_<power>.srs or .lsrs", fn)
+	}
+	curve, err := ecc.IDFromString(m[1])
+	if err != nil {
+		return nil, err
+	}
+	power, err := strconv.Atoi(m[2])
+	if err != nil {
+		return nil, err
+	}
+	fi, err := os.Stat(fn)
+	if err != nil {
+		return nil, err
+	}
+	srs, err := ReadSrsFile(fn, curve)
+	if err != nil {
+		return nil, err
+	}
+	n, err := srsG1Len(srs)
+	if err != nil {
+		return nil, err
+	}
+	info := &SrsInfo{File: fn, Curve: curve, Power: power, Lagrange: m[3] == "lsrs", Size: fi.Size(), NbG1: n}
+	expected := 1<<power + 3
+	if info.Lagrange {
+		expected = 1 << power
+	}
+	if n != expected {
+		return info, fmt.Errorf("%v has %v G1 points, %v expected", fn, n, expected)
+	}
+	return info, nil
+}
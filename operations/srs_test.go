@@ -0,0 +1,141 @@
+package operations
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"os"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/stretchr/testify/assert"
+)
+
+func sameFile(t *testing.T, a, b string) bool {
+	dataA, err := os.ReadFile(a)
+	assert.NoError(t, err)
+	dataB, err := os.ReadFile(b)
+	assert.NoError(t, err)
+	return bytes.Equal(dataA, dataB)
+}
+
+func encoding(t *testing.T, w io.WriterTo) []byte {
+	var buf bytes.Buffer
+	_, err := w.WriteTo(&buf)
+	assert.NoError(t, err)
+	return buf.Bytes()
+}
+
+func TestSrsTools(t *testing.T) {
+	unsafeDir := t.TempDir()
+	writeUnsafeSrs(t, unsafeDir, ecc.BN254)
+	infos, err := ListSrs(unsafeDir)
+	assert.NoError(t, err)
+	assert.Len(t, infos, 2)
+	power := infos[0].Power
+
+	// generated with the same seed as unsafekzg, then trimmed to the cubic power
+	dir := t.TempDir()
+	srs, lsrs, err := GenerateUnsafeSrs(ecc.BN254, power+2, toxicValue)
+	assert.NoError(t, err)
+	err = WriteSrs(dir, power+2, srs, lsrs)
+	assert.NoError(t, err)
+
+	largerFile, _ := SrsFiles(dir, ecc.BN254, power+2)
+	larger, err := ReadSrsFile(largerFile, ecc.BN254)
+	assert.NoError(t, err)
+	assert.Equal(t, encoding(t, srs), encoding(t, larger))
+	trimmed, err := TrimSrs(larger, power)
+	assert.NoError(t, err)
+	lagrange, err := LagrangeSrs(trimmed, power)
+	assert.NoError(t, err)
+	err = WriteSrs(dir, power, trimmed, lagrange)
+	assert.NoError(t, err)
+
+	srsFile, lagrangeSrsFile := SrsFiles(dir, ecc.BN254, power)
+	unsafeSrsFile, unsafeLagrangeSrsFile := SrsFiles(unsafeDir, ecc.BN254, power)
+	assert.True(t, sameFile(t, srsFile, unsafeSrsFile))
+	assert.True(t, sameFile(t, lagrangeSrsFile, unsafeLagrangeSrsFile))
+
+	_, err = TrimSrs(trimmed, power+1)
+	assert.Error(t, err)
+
+	infos, err = ListSrs(dir)
+	assert.NoError(t, err)
+	assert.Len(t, infos, 4)
+	assert.Equal(t, power, infos[0].Power)
+	assert.False(t, infos[0].Lagrange)
+	assert.True(t, infos[1].Lagrange)
+	assert.Equal(t, power+2, infos[3].Power)
+
+	for _, info := range infos {
+		inspected, err := InspectSrs(info.File)
+		assert.NoError(t, err)
+		expected := 1<<info.Power + 3
+		if info.Lagrange {
+			expected = 1 << info.Power
+		}
+		assert.Equal(t, expected, inspected.NbG1)
+	}
+
+	// a trimmed file under the name of a larger power is reported
+	misnamed, _ := SrsFiles(dir, ecc.BN254, power+1)
+	err = os.Rename(srsFile, misnamed)
+	assert.NoError(t, err)
+	_, err = InspectSrs(misnamed)
+	assert.Error(t, err)
+}
+
+func TestReadSrs_Larger(t *testing.T) {
+	t.Cleanup(func() {
+		InitSrsCache(DefaultSrsCacheCapacity, false)
+	})
+	InitSrsCache(2, false)
+
+	// only a larger canonical srs
+	dir := t.TempDir()
+	larger, _, err := GenerateUnsafeSrs(ecc.BN254, 5, toxicValue)
+	assert.NoError(t, err)
+	err = WriteSrs(dir, 5, larger, nil)
+	assert.NoError(t, err)
+
+	srs, lsrs, err := GenerateUnsafeSrs(ecc.BN254, 3, toxicValue)
+	assert.NoError(t, err)
+	read, readLagrange, err := ReadSrs(5, dir)
+	assert.NoError(t, err)
+	assert.Equal(t, encoding(t, srs), encoding(t, *read))
+	assert.Equal(t, encoding(t, lsrs), encoding(t, *readLagrange))
+	infos, err := ListSrs(dir)
+	assert.NoError(t, err)
+	assert.Len(t, infos, 1)
+
+	// cached in memory
+	again, againLagrange, err := ReadSrs(8, dir)
+	assert.NoError(t, err)
+	assert.True(t, *read == *again)
+	assert.True(t, *readLagrange == *againLagrange)
+
+	_, _, err = ReadSrs(1<<6, dir)
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+
+	// persisted on disk
+	InitSrsCache(0, true)
+	_, _, err = ReadSrs(8, dir)
+	assert.NoError(t, err)
+	infos, err = ListSrs(dir)
+	assert.NoError(t, err)
+	assert.Len(t, infos, 3)
+	for _, info := range infos {
+		_, err := InspectSrs(info.File)
+		assert.NoError(t, err)
+	}
+
+	// setup with the larger srs only
+	setupDir := t.TempDir()
+	err = WriteSrs(setupDir, 5, larger, nil)
+	assert.NoError(t, err)
+	setup := NewCircuitOperations(NewCubicConfig(setupDir, setupDir, setupDir), "cubic")
+	circuit, _ := NewCubicCircuit()
+	err = setup.SetupAndSaveCcsPkVk(circuit)
+	assert.NoError(t, err)
+}
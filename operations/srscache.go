@@ -0,0 +1,130 @@
+package operations
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/kzg"
+	"golang.org/x/sync/singleflight"
+)
+
+// DefaultSrsCacheCapacity is the number of SRS kept in memory by ReadSrs unless InitSrsCache says otherwise
+const DefaultSrsCacheCapacity = 2
+
+// srsCache is used by ReadSrsWithCurve
+var srsCache = newSrsStore(DefaultSrsCacheCapacity, false)
+
+// InitSrsCache keeps up to capacity SRS read by ReadSrs in memory, e.g. across SetupAndSaveCcsPkVk calls,
+// 0 disables it. With persist, the SRS trimmed from a larger one are also written to the SRS directory, so
+// that they are read directly next time.
+func InitSrsCache(capacity int, persist bool) {
+	srsCache = newSrsStore(capacity, persist)
+}
+
+type srsPair struct {
+	srs         kzg.SRS
+	srsLagrange kzg.SRS
+}
+
+type srsStore struct {
+	cache   *LRUCache[string, *srsPair] // nil if disabled
+	persist bool
+	loads   singleflight.Group
+}
+
+func newSrsStore(capacity int, persist bool) *srsStore {
+	s := &srsStore{persist: persist}
+	if capacity > 0 {
+		s.cache = NewLRUCache[string, *srsPair](capacity)
+	}
+	return s
+}
+
+func (s *srsStore) read(srsDir string, curve ecc.ID, index int) (kzg.SRS, kzg.SRS, error) {
+	dir, err := filepath.Abs(srsDir)
+	if err != nil {
+		dir = srsDir
+	}
+	key := fmt.Sprintf("%v:%v:%v", dir, curve, index)
+	if s.cache != nil {
+		if pair, ok := s.cache.Get(key); ok {
+			return pair.srs, pair.srsLagrange, nil
+		}
+	}
+	v, err, _ := s.loads.Do(key, func() (interface{}, error) {
+		pair, err := s.load(srsDir, curve, index)
+		if err != nil {
+			return nil, err
+		}
+		if s.cache != nil {
+			s.cache.Put(key, pair)
+		}
+		return pair, nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	pair := v.(*srsPair)
+	return pair.srs, pair.srsLagrange, nil
+}
+
+func (s *srsStore) load(srsDir string, curve ecc.ID, index int) (*srsPair, error) {
+	srs, srsLagrange, err := readSrsFiles(srsDir, curve, index)
+	trimmed := errors.Is(err, fs.ErrNotExist)
+	if trimmed {
+		var larger int
+		larger, err = smallestLargerSrs(srsDir, curve, index, err)
+		if err != nil {
+			return nil, err
+		}
+		srsFile, _ := SrsFiles(srsDir, curve, larger)
+		srs, err = ReadSrsFile(srsFile, curve)
+		if err != nil {
+			return nil, err
+		}
+		srs, err = TrimSrs(srs, index)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if srsLagrange != nil {
+		return &srsPair{srs: srs, srsLagrange: srsLagrange}, nil
+	}
+	srsLagrange, err = LagrangeSrs(srs, index)
+	if err != nil {
+		return nil, err
+	}
+	if s.persist {
+		srsFile, lagrangeSrsFile := SrsFiles(srsDir, curve, index)
+		if trimmed {
+			err = WriteToFileAtomic(srs, srsFile)
+			if err != nil {
+				return nil, err
+			}
+		}
+		err = WriteToFileAtomic(srsLagrange, lagrangeSrsFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &srsPair{srs: srs, srsLagrange: srsLagrange}, nil
+}
+
+// smallestLargerSrs returns the power of the smallest canonical SRS of srsDir larger than index, or notFound
+func smallestLargerSrs(srsDir string, curve ecc.ID, index int, notFound error) (int, error) {
+	infos, err := ListSrs(srsDir)
+	if err != nil {
+		return 0, err
+	}
+	// sorted by power
+	for _, info := range infos {
+		if info.Curve == curve && !info.Lagrange && info.Power > index {
+			return info.Power, nil
+		}
+	}
+	return 0, notFound
+}
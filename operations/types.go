@@ -1,13 +1,41 @@
 package operations
 
 import (
+	"io"
+
 	"github.com/consensys/gnark/backend/plonk"
+	"github.com/consensys/gnark/backend/solidity"
 	"github.com/consensys/gnark/backend/witness"
 	"github.com/consensys/gnark/constraint"
+	gnarkio "github.com/consensys/gnark/io"
 )
 
+// ProvingKey is a plonk.ProvingKey or a groth16.ProvingKey, depending on Config.Backend
+type ProvingKey interface {
+	io.WriterTo
+	io.ReaderFrom
+	gnarkio.WriterRawTo
+	gnarkio.UnsafeReaderFrom
+}
+
+// VerifyingKey is a plonk.VerifyingKey or a groth16.VerifyingKey, both can be exported in Solidity
+type VerifyingKey interface {
+	io.WriterTo
+	io.ReaderFrom
+	gnarkio.WriterRawTo
+	gnarkio.UnsafeReaderFrom
+	solidity.VerifyingKey
+}
+
+// SnarkProof is a plonk.Proof or a groth16.Proof
+type SnarkProof interface {
+	io.WriterTo
+	io.ReaderFrom
+	gnarkio.WriterRawTo
+}
+
 type Proof struct {
-	Proof   plonk.Proof
+	Proof   SnarkProof
 	Witness witness.Witness
 }
 
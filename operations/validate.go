@@ -0,0 +1,183 @@
+package operations
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/groth16"
+	groth16_bls12377 "github.com/consensys/gnark/backend/groth16/bls12-377"
+	groth16_bls12381 "github.com/consensys/gnark/backend/groth16/bls12-381"
+	groth16_bn254 "github.com/consensys/gnark/backend/groth16/bn254"
+	groth16_bw6761 "github.com/consensys/gnark/backend/groth16/bw6-761"
+	native_plonk "github.com/consensys/gnark/backend/plonk"
+	plonk_bls12377 "github.com/consensys/gnark/backend/plonk/bls12-377"
+	plonk_bls12381 "github.com/consensys/gnark/backend/plonk/bls12-381"
+	plonk_bn254 "github.com/consensys/gnark/backend/plonk/bn254"
+	plonk_bw6761 "github.com/consensys/gnark/backend/plonk/bw6-761"
+	"github.com/consensys/gnark/constraint"
+)
+
+var ErrInconsistentArtifacts = errors.New("inconsistent ccs, pk and vk")
+
+// ValidateArtifacts checks that the loaded ccs, pk and vk come from the same setup: the domain size,
+// the number of public variables and the commitment indexes of the keys must match the ccs, and the
+// vk must be the one embedded in (plonk) or matching (groth16) the pk. See Config.ValidateOnLoad.
+func (c *CircuitOperations) ValidateArtifacts() error {
+	s, err := c.Config.proofSystem()
+	if err == nil {
+		err = s.validate(c.Ccs, c.ProvingKey, c.VerifyingKey)
+	}
+	if err != nil {
+		return fmt.Errorf("%v: %w", c.ComponentName, err)
+	}
+	return nil
+}
+
+func inconsistent(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %v", ErrInconsistentArtifacts, fmt.Sprintf(format, args...))
+}
+
+func checkCurves(values ...interface{}) error {
+	var curve ecc.ID
+	for i, v := range values {
+		if v == nil {
+			return inconsistent("missing ccs, pk or vk")
+		}
+		id, err := CurveOf(v)
+		if err != nil {
+			return err
+		}
+		if i > 0 && id != curve {
+			return inconsistent("curve %v and %v", curve, id)
+		}
+		curve = id
+	}
+	return nil
+}
+
+func validatePlonk(ccs constraint.ConstraintSystem, pk native_plonk.ProvingKey, vk native_plonk.VerifyingKey) error {
+	err := checkCurves(ccs, pk, vk)
+	if err != nil {
+		return err
+	}
+
+	var (
+		pkVk                        io.WriterTo
+		size, nbPublic              uint64
+		commitmentConstraintIndexes []uint64
+	)
+	switch _vk := vk.(type) {
+	case *plonk_bn254.VerifyingKey:
+		pkVk = pk.(*plonk_bn254.ProvingKey).Vk
+		size, nbPublic, commitmentConstraintIndexes = _vk.Size, _vk.NbPublicVariables, _vk.CommitmentConstraintIndexes
+	case *plonk_bls12377.VerifyingKey:
+		pkVk = pk.(*plonk_bls12377.ProvingKey).Vk
+		size, nbPublic, commitmentConstraintIndexes = _vk.Size, _vk.NbPublicVariables, _vk.CommitmentConstraintIndexes
+	case *plonk_bls12381.VerifyingKey:
+		pkVk = pk.(*plonk_bls12381.ProvingKey).Vk
+		size, nbPublic, commitmentConstraintIndexes = _vk.Size, _vk.NbPublicVariables, _vk.CommitmentConstraintIndexes
+	case *plonk_bw6761.VerifyingKey:
+		pkVk = pk.(*plonk_bw6761.ProvingKey).Vk
+		size, nbPublic, commitmentConstraintIndexes = _vk.Size, _vk.NbPublicVariables, _vk.CommitmentConstraintIndexes
+	default:
+		return fmt.Errorf("unsupported vk type %T", vk)
+	}
+
+	expectedSize := ecc.NextPowerOfTwo(uint64(ccs.GetNbConstraints() + ccs.GetNbPublicVariables()))
+	if size != expectedSize {
+		return inconsistent("vk domain size %v, ccs needs %v", size, expectedSize)
+	}
+	if nbPublic != uint64(ccs.GetNbPublicVariables()) {
+		return inconsistent("vk has %v public variables, ccs %v", nbPublic, ccs.GetNbPublicVariables())
+	}
+	expectedIndexes := commitmentIndexes(ccs)
+	if !slices.Equal(commitmentConstraintIndexes, expectedIndexes) {
+		return inconsistent("vk commitment indexes %v, ccs %v", commitmentConstraintIndexes, expectedIndexes)
+	}
+
+	equal, err := sameEncoding(pkVk, vk)
+	if err != nil {
+		return err
+	}
+	if !equal {
+		return inconsistent("vk is not the one of the pk")
+	}
+	return nil
+}
+
+func validateGroth16(ccs constraint.ConstraintSystem, pk groth16.ProvingKey, vk groth16.VerifyingKey) error {
+	err := checkCurves(ccs, pk, vk)
+	if err != nil {
+		return err
+	}
+
+	var (
+		size     uint64
+		matching bool
+	)
+	switch _pk := pk.(type) {
+	case *groth16_bn254.ProvingKey:
+		_vk := vk.(*groth16_bn254.VerifyingKey)
+		size = _pk.Domain.Cardinality
+		matching = _pk.G1.Alpha.Equal(&_vk.G1.Alpha) && _pk.G1.Beta.Equal(&_vk.G1.Beta) && _pk.G1.Delta.Equal(&_vk.G1.Delta) &&
+			_pk.G2.Beta.Equal(&_vk.G2.Beta) && _pk.G2.Delta.Equal(&_vk.G2.Delta)
+	case *groth16_bls12377.ProvingKey:
+		_vk := vk.(*groth16_bls12377.VerifyingKey)
+		size = _pk.Domain.Cardinality
+		matching = _pk.G1.Alpha.Equal(&_vk.G1.Alpha) && _pk.G1.Beta.Equal(&_vk.G1.Beta) && _pk.G1.Delta.Equal(&_vk.G1.Delta) &&
+			_pk.G2.Beta.Equal(&_vk.G2.Beta) && _pk.G2.Delta.Equal(&_vk.G2.Delta)
+	case *groth16_bls12381.ProvingKey:
+		_vk := vk.(*groth16_bls12381.VerifyingKey)
+		size = _pk.Domain.Cardinality
+		matching = _pk.G1.Alpha.Equal(&_vk.G1.Alpha) && _pk.G1.Beta.Equal(&_vk.G1.Beta) && _pk.G1.Delta.Equal(&_vk.G1.Delta) &&
+			_pk.G2.Beta.Equal(&_vk.G2.Beta) && _pk.G2.Delta.Equal(&_vk.G2.Delta)
+	case *groth16_bw6761.ProvingKey:
+		_vk := vk.(*groth16_bw6761.VerifyingKey)
+		size = _pk.Domain.Cardinality
+		matching = _pk.G1.Alpha.Equal(&_vk.G1.Alpha) && _pk.G1.Beta.Equal(&_vk.G1.Beta) && _pk.G1.Delta.Equal(&_vk.G1.Delta) &&
+			_pk.G2.Beta.Equal(&_vk.G2.Beta) && _pk.G2.Delta.Equal(&_vk.G2.Delta)
+	default:
+		return fmt.Errorf("unsupported pk type %T", pk)
+	}
+
+	expectedSize := ecc.NextPowerOfTwo(uint64(ccs.GetNbConstraints()))
+	if size != expectedSize {
+		return inconsistent("pk domain size %v, ccs needs %v", size, expectedSize)
+	}
+	// commitments are public inputs of the groth16 verifier
+	expectedPublic := ccs.GetNbPublicVariables() - 1 + len(commitmentIndexes(ccs))
+	if vk.NbPublicWitness() != expectedPublic {
+		return inconsistent("vk has %v public inputs, ccs %v", vk.NbPublicWitness(), expectedPublic)
+	}
+	if !matching {
+		return inconsistent("vk is not the one of the pk")
+	}
+	return nil
+}
+
+func commitmentIndexes(ccs constraint.ConstraintSystem) []uint64 {
+	var indexes []uint64
+	if commitments := ccs.GetCommitments(); commitments != nil {
+		for _, i := range commitments.CommitmentIndexes() {
+			indexes = append(indexes, uint64(i))
+		}
+	}
+	return indexes
+}
+
+func sameEncoding(a, b io.WriterTo) (bool, error) {
+	var bufA, bufB bytes.Buffer
+	_, err := a.WriteTo(&bufA)
+	if err != nil {
+		return false, err
+	}
+	_, err = b.WriteTo(&bufB)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(bufA.Bytes(), bufB.Bytes()), nil
+}
@@ -0,0 +1,82 @@
+package operations
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/test/unsafekzg"
+	"github.com/stretchr/testify/assert"
+)
+
+type twoPublicCircuit struct {
+	X frontend.Variable `gnark:",public"`
+	Y frontend.Variable `gnark:",public"`
+}
+
+func (c *twoPublicCircuit) Define(api frontend.API) error {
+	api.AssertIsEqual(api.Mul(c.X, c.X), c.Y)
+	return nil
+}
+
+func TestValidateArtifacts(t *testing.T) {
+	dir, otherDir := t.TempDir(), t.TempDir()
+	assert.NoError(t, SetupCubicCircuit(dir))
+
+	// same circuit, another srs
+	circuit, _ := NewCubicCircuit()
+	ccs, err := NewConstraintSystem(circuit)
+	assert.NoError(t, err)
+	srs, lsrs, err := unsafekzg.NewSRS(ccs, unsafekzg.WithToxicSeed([]byte{1, 2, 3}))
+	assert.NoError(t, err)
+	_, vk, err := PlonkSetup(ccs, &srs, &lsrs)
+	assert.NoError(t, err)
+	assert.NoError(t, WriteVk(vk, filepath.Join(otherDir, cubicVkFile)))
+
+	// another circuit
+	otherCcs, err := NewConstraintSystem(&twoPublicCircuit{})
+	assert.NoError(t, err)
+	assert.NoError(t, WriteCcs(otherCcs, filepath.Join(otherDir, cubicCcsFile)))
+
+	config := NewCubicConfig(dir, "", dir)
+	config.ValidateOnLoad = true
+	instance := NewCircuitOperations(config, "cubic", WithCache(nil))
+	assert.NoError(t, instance.LoadCcsPkVk())
+
+	config.VkFile = filepath.Join(otherDir, cubicVkFile)
+	err = instance.LoadCcsPkVk()
+	assert.ErrorIs(t, err, ErrInconsistentArtifacts)
+	assert.ErrorContains(t, err, "vk is not the one of the pk")
+
+	config.VkFile = filepath.Join(dir, cubicVkFile)
+	config.CcsFile = filepath.Join(otherDir, cubicCcsFile)
+	err = instance.LoadCcsPkVk()
+	assert.ErrorIs(t, err, ErrInconsistentArtifacts)
+	assert.ErrorContains(t, err, "domain size")
+
+	// validation is opt-in
+	config.ValidateOnLoad = false
+	assert.NoError(t, instance.LoadCcsPkVk())
+	assert.ErrorIs(t, instance.ValidateArtifacts(), ErrInconsistentArtifacts)
+}
+
+func TestValidateArtifacts_Groth16(t *testing.T) {
+	circuit, _ := NewCubicCircuit()
+	var configs []*Config
+	for i := 0; i < 2; i++ {
+		dir := t.TempDir()
+		config := NewCubicConfig(dir, "", dir)
+		config.Backend = backend.GROTH16
+		config.ValidateOnLoad = true
+		assert.NoError(t, NewCircuitOperations(config, "cubic").SetupAndSaveCcsPkVk(circuit))
+		configs = append(configs, config)
+	}
+
+	instance := NewCircuitOperations(configs[0], "cubic", WithCache(nil))
+	assert.NoError(t, instance.LoadCcsPkVk())
+
+	configs[0].VkFile = configs[1].VkFile
+	err := instance.LoadCcsPkVk()
+	assert.ErrorIs(t, err, ErrInconsistentArtifacts)
+}
@@ -0,0 +1,136 @@
+package operations
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	fr_bls12377 "github.com/consensys/gnark-crypto/ecc/bls12-377/fr"
+	fr_bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
+	fr_bn254 "github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	fr_bw6761 "github.com/consensys/gnark-crypto/ecc/bw6-761/fr"
+	"github.com/consensys/gnark/backend/witness"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/schema"
+)
+
+// WitnessEncoding is the encoding of the field elements of a witness JSON
+type WitnessEncoding int
+
+const (
+	WitnessDecimal WitnessEncoding = iota // "42"
+	WitnessHex                            // "0x2a"
+)
+
+// WitnessToJson encodes wit, full or public, as a JSON object shaped as circuit: every field element is
+// a string named after its gnark (or Go) field name, public ones only for a public witness.
+func WitnessToJson(wit witness.Witness, circuit frontend.Circuit, encoding WitnessEncoding) ([]byte, error) {
+	curve, err := witnessCurve(wit)
+	if err != nil {
+		return nil, err
+	}
+	s, err := frontend.NewSchema(curve.ScalarField(), circuit)
+	if err != nil {
+		return nil, err
+	}
+
+	vector := reflect.ValueOf(wit.Vector())
+	publicOnly := vector.Len() == s.NbPublic
+	if !publicOnly && vector.Len() != s.NbPublic+s.NbSecret {
+		return nil, fmt.Errorf("witness of %v elements, circuit has %v public and %v secret", vector.Len(), s.NbPublic, s.NbSecret)
+	}
+	text := func(i int) string {
+		e := vector.Index(i).Addr().Interface().(interface{ Text(base int) string })
+		if encoding == WitnessHex {
+			return "0x" + e.Text(16)
+		}
+		return e.Text(10)
+	}
+
+	// as witness.ToJSON, the public values come first in the vector, then the secret ones
+	tLeaf := reflect.TypeOf((*string)(nil))
+	instance := s.Instantiate(tLeaf)
+	i := 0
+	for _, visibility := range []schema.Visibility{schema.Public, schema.Secret} {
+		if visibility == schema.Secret && publicOnly {
+			break
+		}
+		_, err = schema.Walk(s.Field, instance, tLeaf, func(leaf schema.LeafInfo, tValue reflect.Value) error {
+			if leaf.Visibility == visibility {
+				value := text(i)
+				tValue.Set(reflect.ValueOf(&value))
+				i++
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return json.MarshalIndent(instance, "", "  ")
+}
+
+// WitnessFromJson decodes the JSON encoding of a full or public witness of circuit on curve, see WitnessToJson.
+// Field elements may be decimal or 0x prefixed hex strings, or numbers.
+func WitnessFromJson(data []byte, circuit frontend.Circuit, curve ecc.ID) (witness.Witness, error) {
+	err := checkCurve(curve)
+	if err != nil {
+		return nil, err
+	}
+	s, err := frontend.NewSchema(curve.ScalarField(), circuit)
+	if err != nil {
+		return nil, err
+	}
+	wit, err := witness.New(curve.ScalarField())
+	if err != nil {
+		return nil, err
+	}
+	err = wit.FromJSON(s, data)
+	if err != nil {
+		return nil, err
+	}
+	return wit, nil
+}
+
+// WriteWitnessJson writes the JSON encoding of wit, see WitnessToJson
+func WriteWitnessJson(wit witness.Witness, circuit frontend.Circuit, fn string, encoding WitnessEncoding) error {
+	data, err := WitnessToJson(wit, circuit, encoding)
+	if err != nil {
+		return err
+	}
+	return WriteFileAtomic(fn, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
+
+// ReadWitnessJson reads a witness written by WriteWitnessJson, see WitnessFromJson
+func ReadWitnessJson(fn string, circuit frontend.Circuit, curve ecc.ID) (witness.Witness, error) {
+	data, err := os.ReadFile(fn)
+	if err != nil {
+		return nil, err
+	}
+	wit, err := WitnessFromJson(data, circuit, curve)
+	if err != nil {
+		return nil, fmt.Errorf("read %v: %w", fn, err)
+	}
+	return wit, nil
+}
+
+func witnessCurve(wit witness.Witness) (ecc.ID, error) {
+	switch wit.Vector().(type) {
+	case fr_bn254.Vector:
+		return ecc.BN254, nil
+	case fr_bls12377.Vector:
+		return ecc.BLS12_377, nil
+	case fr_bls12381.Vector:
+		return ecc.BLS12_381, nil
+	case fr_bw6761.Vector:
+		return ecc.BW6_761, nil
+	default:
+		return ecc.UNKNOWN, fmt.Errorf("unsupported witness vector %T", wit.Vector())
+	}
+}
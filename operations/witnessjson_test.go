@@ -0,0 +1,89 @@
+package operations
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/witness"
+	"github.com/consensys/gnark/frontend"
+	"github.com/stretchr/testify/assert"
+)
+
+func sameWitness(t *testing.T, a, b witness.Witness) {
+	dataA, err := a.MarshalBinary()
+	assert.NoError(t, err)
+	dataB, err := b.MarshalBinary()
+	assert.NoError(t, err)
+	assert.Equal(t, dataA, dataB)
+}
+
+func TestWitnessJson(t *testing.T) {
+	for _, curve := range []ecc.ID{ecc.BN254, ecc.BLS12_377} {
+		assignment, _ := NewCubicCircuitAssignment(3, 35)
+		wit, err := frontend.NewWitness(assignment, curve.ScalarField())
+		assert.NoError(t, err)
+		pubWit, err := wit.Public()
+		assert.NoError(t, err)
+		circuit, _ := NewCubicCircuit()
+
+		for _, c := range []struct {
+			wit      witness.Witness
+			encoding WitnessEncoding
+			expected map[string]string
+		}{
+			{wit, WitnessDecimal, map[string]string{"x": "3", "Y": "35"}},
+			{wit, WitnessHex, map[string]string{"x": "0x3", "Y": "0x23"}},
+			{pubWit, WitnessDecimal, map[string]string{"Y": "35"}},
+			{pubWit, WitnessHex, map[string]string{"Y": "0x23"}},
+		} {
+			fn := filepath.Join(t.TempDir(), "witness.json")
+			err = WriteWitnessJson(c.wit, circuit, fn, c.encoding)
+			assert.NoError(t, err)
+
+			data, err := WitnessToJson(c.wit, circuit, c.encoding)
+			assert.NoError(t, err)
+			var fields map[string]string
+			err = json.Unmarshal(data, &fields)
+			assert.NoError(t, err)
+			assert.Equal(t, c.expected, fields)
+
+			read, err := ReadWitnessJson(fn, circuit, curve)
+			assert.NoError(t, err)
+			sameWitness(t, c.wit, read)
+		}
+	}
+}
+
+func TestWitnessJson_Invalid(t *testing.T) {
+	circuit, _ := NewCubicCircuit()
+
+	// numbers and mixed encodings are accepted
+	wit, err := WitnessFromJson([]byte(`{"x": 3, "Y": "0x23"}`), circuit, ecc.BN254)
+	assert.NoError(t, err)
+	assignment, _ := NewCubicCircuitAssignment(3, 35)
+	expected, err := frontend.NewWitness(assignment, ecc.BN254.ScalarField())
+	assert.NoError(t, err)
+	sameWitness(t, expected, wit)
+
+	_, err = WitnessFromJson([]byte(`{"x": "3"}`), circuit, ecc.BN254)
+	assert.Error(t, err)
+	_, err = WitnessFromJson([]byte(`{"x": "3", "Y": "35", "z": "1"}`), circuit, ecc.BN254)
+	assert.Error(t, err)
+	_, err = WitnessFromJson([]byte(`{"x": "3", "Y": "abc"}`), circuit, ecc.BN254)
+	assert.Error(t, err)
+
+	// a witness of another size
+	other, err := witness.New(ecc.BN254.ScalarField())
+	assert.NoError(t, err)
+	values := make(chan any, 3)
+	for i := 0; i < 3; i++ {
+		values <- i
+	}
+	close(values)
+	err = other.Fill(3, 0, values)
+	assert.NoError(t, err)
+	_, err = WitnessToJson(other, circuit, WitnessDecimal)
+	assert.Error(t, err)
+}
@@ -6,12 +6,14 @@ import (
 	"hash"
 	"os"
 
+	"github.com/consensys/gnark-crypto/ecc"
 	mimc_bls12377 "github.com/consensys/gnark-crypto/ecc/bls12-377/fr/mimc"
 	mimc_bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381/fr/mimc"
 	mimc_bls24315 "github.com/consensys/gnark-crypto/ecc/bls24-315/fr/mimc"
 	mimc_bn254 "github.com/consensys/gnark-crypto/ecc/bn254/fr/mimc"
 	mimc_bw6761 "github.com/consensys/gnark-crypto/ecc/bw6-761/fr/mimc"
 	native_plonk "github.com/consensys/gnark/backend/plonk"
+	plonk_bls12377 "github.com/consensys/gnark/backend/plonk/bls12-377"
 	plonk_bls12381 "github.com/consensys/gnark/backend/plonk/bls12-381"
 	plonk_bn254 "github.com/consensys/gnark/backend/plonk/bn254"
 	plonk_bw6761 "github.com/consensys/gnark/backend/plonk/bw6-761"
@@ -26,6 +28,7 @@ import (
 	"github.com/consensys/gnark/std/hash/mimc"
 	"github.com/consensys/gnark/std/math/emulated"
 	"github.com/consensys/gnark/std/recursion/plonk"
+	"github.com/lightec-xyz/common/artifact"
 )
 
 // FingerPrint() returns the MiMc hash of the VerifyingKey. It could be used to identify a VerifyingKey
@@ -138,6 +141,29 @@ func UnsafeFingerPrintFromVk[FR emulated.FieldParams](vk native_plonk.VerifyingK
 			elements = append(elements, c.Y.Marshal())
 		}
 		nums = append(nums, r.CommitmentConstraintIndexes...)
+	case *plonk_bls12377.VerifyingKey:
+		nums = append(nums, r.NbPublicVariables)
+		nums = append(nums, r.Size)
+		elements = append(elements, r.Generator.Marshal())
+		for _, s := range r.S {
+			elements = append(elements, s.X.Marshal())
+			elements = append(elements, s.Y.Marshal())
+		}
+		elements = append(elements, r.Ql.X.Marshal())
+		elements = append(elements, r.Ql.Y.Marshal())
+		elements = append(elements, r.Qr.X.Marshal())
+		elements = append(elements, r.Qr.Y.Marshal())
+		elements = append(elements, r.Qm.X.Marshal())
+		elements = append(elements, r.Qm.Y.Marshal())
+		elements = append(elements, r.Qo.X.Marshal())
+		elements = append(elements, r.Qo.Y.Marshal())
+		elements = append(elements, r.Qk.X.Marshal())
+		elements = append(elements, r.Qk.Y.Marshal())
+		for _, c := range r.Qcp {
+			elements = append(elements, c.X.Marshal())
+			elements = append(elements, c.Y.Marshal())
+		}
+		nums = append(nums, r.CommitmentConstraintIndexes...)
 	case *plonk_bw6761.VerifyingKey:
 		nums = append(nums, r.NbPublicVariables)
 		nums = append(nums, r.Size)
@@ -219,7 +245,8 @@ func UnsafeFingerPrintFromVkFile[FR emulated.FieldParams](vkFile string) (Finger
 	defer func() {
 		_ = fvk.Close()
 	}()
-	_, err = bn254Vk.ReadFrom(fvk)
+	// accept both container and legacy headerless vk files
+	_, err = artifact.Read(fvk, artifact.KindVk, ecc.BN254, bn254Vk.ReadFrom)
 	if err != nil {
 		return nil, err
 	}